@@ -0,0 +1,10 @@
+// Package assets embeds the templates shipped with dbdump, so that they are
+// available outside of the repository.
+package assets
+
+import "embed"
+
+// FS holds the shipped templates, e.g. gotext_md.fc
+//
+//go:embed *.fc *.tmpl html
+var FS embed.FS
@@ -0,0 +1,44 @@
+// sort the rows of a sortable table by the clicked column
+document.querySelectorAll("table.sortable th").forEach(function (th) {
+  th.addEventListener("click", function () {
+    var table = th.closest("table");
+    var tbody = table.tBodies[0];
+    var index = Array.prototype.indexOf.call(th.parentNode.children, th);
+    var asc = !th.classList.contains("asc");
+    table.querySelectorAll("th").forEach(function (other) {
+      other.classList.remove("asc", "desc");
+    });
+    th.classList.add(asc ? "asc" : "desc");
+
+    var rows = Array.prototype.slice.call(tbody.rows);
+    rows.sort(function (a, b) {
+      var x = a.cells[index].textContent.trim();
+      var y = b.cells[index].textContent.trim();
+      var result = x !== "" && y !== "" && !isNaN(x) && !isNaN(y) ? x - y : x.localeCompare(y);
+      return asc ? result : -result;
+    });
+    rows.forEach(function (row) {
+      tbody.appendChild(row);
+    });
+  });
+});
+
+// filter the tables by the search text
+var search = document.getElementById("search");
+if (search) {
+  search.addEventListener("input", function () {
+    var words = search.value.toLowerCase().split(/\s+/).filter(Boolean);
+    document.querySelectorAll("section.schema").forEach(function (section) {
+      var visible = 0;
+      section.querySelectorAll("tr[data-search]").forEach(function (row) {
+        var text = row.getAttribute("data-search");
+        var match = words.every(function (word) {
+          return text.indexOf(word) >= 0;
+        });
+        row.classList.toggle("hidden", !match);
+        visible += match ? 1 : 0;
+      });
+      section.classList.toggle("hidden", visible === 0);
+    });
+  });
+}
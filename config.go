@@ -1,6 +1,10 @@
 package main
 
-import "github.com/Nutao/dbdump/formatter"
+import (
+	"net/url"
+
+	"github.com/Nutao/dbdump/formatter"
+)
 
 var (
 	gConfig = &config{}
@@ -8,15 +12,34 @@ var (
 
 //
 type config struct {
-	DBType   string // db类型
+	DBType   string     // db类型
+	DSN      string     // 连接URL，指定时覆盖db类型及host、port、user等参数
+	Params   url.Values // 连接URL中传给驱动的参数
 	Host     string
 	Port     uint
+	Socket   string // unix socket文件(pgsql可为其目录)，auto查找默认位置，指定时不使用host、port连接
 	User     string
 	Password string
 	Database string
 	Tables   []string
+	Schemas  []string // pgsql导出的schema，可使用通配符，!开头的为排除，默认为所有非系统schema
+	Snapshot string   // json格式输出的快照文件，指定时不连接数据库
+	DDLFiles []string // 解析的DDL文件或目录，指定时不连接数据库
+
+	// TLS连接参数，SSLMode为disable、require、verify-ca或verify-full
+	SSLMode       string
+	SSLCA         string
+	SSLCert       string
+	SSLKey        string
+	SSLServerName string
+
+	// 通过参数、环境变量或配置文件指定的连接参数(host, port, socket, user, password)，
+	// 其余的从凭据文件读取
+	Given map[string]bool
 
 	Output       string
+	Split        string // table或schema，按表或库分别输出到Output路径模板
+	Index        string // 分别输出时生成的索引文件
 	Formatter    formatter.Formatter
 	FormatType   string
 	FormatConfig string
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/Nutao/dbdump/formatter"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// configFiles are the config files read if --config is not given, the first
+// existing one is used. ~ is the home directory.
+var configFiles = []string{
+	"dbdump.yaml", "dbdump.yml", "dbdump.toml",
+	"~/.config/dbdump/config.yaml", "~/.config/dbdump/config.yml", "~/.config/dbdump/config.toml",
+	"~/.config/dbdump.yaml", "~/.config/dbdump.yml", "~/.config/dbdump.toml",
+}
+
+// applyConfigFile sets the flags not given on the command line from the config
+// file. The keys of the file are the names of the global flags, the values of
+// the profile selected by --profile, or by the profile key of the file,
+// override the values at the top level, e.g.
+//
+//	format_type: markdown
+//	format_config:
+//	  lang: en
+//	profile: dev
+//	profiles:
+//	  dev:
+//	    host: 127.0.0.1
+//	    password: secret
+//	    database: shop
+//	  prod:
+//	    host: db.example.com
+//	    tables: [users, orders]
+//
+// Maps are only accepted by format_config of the formatters configured by
+// key=value pairs, i.e. all but gotext.
+func applyConfigFile(ctx *cli.Context, file, profile string) error {
+	if file == "" {
+		file = findConfigFile()
+		if file == "" {
+			if profile != "" {
+				return fmt.Errorf("profile %v is given without config file", profile)
+			}
+			return nil
+		}
+	}
+
+	values, err := loadConfigFile(file, profile)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	optionMap := false
+	for _, key := range keys {
+		f := lookupFlag(ctx.App.Flags, key)
+		if f == nil || key == "config" || key == "profile" {
+			return fmt.Errorf("unknown key %v in config file %v", key, file)
+		}
+		if flagIsSet(ctx, f) {
+			continue
+		}
+		if err := setFlag(ctx, f, values[key]); err != nil {
+			return fmt.Errorf("invalid %v in config file %v, %w", key, file, err)
+		}
+		if _, ok := values[key].(map[string]interface{}); ok {
+			optionMap = true
+		}
+	}
+
+	// the template formatters take a template instead of key=value pairs,
+	// the format type is known once all flags are set
+	if optionMap {
+		if f, err := formatter.NewFormatter(ctx.String("format_type")); err == nil {
+			if _, ok := f.(formatter.TemplateFormatter); ok {
+				return fmt.Errorf("invalid format_config in config file %v, the %v format takes a template instead of a map",
+					file, ctx.String("format_type"))
+			}
+		}
+	}
+	return nil
+}
+
+// findConfigFile returns the first existing file of configFiles, or empty.
+func findConfigFile() string {
+	home, _ := os.UserHomeDir()
+	for _, file := range configFiles {
+		if strings.HasPrefix(file, "~/") {
+			if home == "" {
+				continue
+			}
+			file = filepath.Join(home, file[2:])
+		}
+		if _, err := os.Stat(file); err == nil {
+			return file
+		}
+	}
+	return ""
+}
+
+// loadConfigFile returns the values of the profile merged over the top level
+// values of the yaml or toml file.
+func loadConfigFile(file, profile string) (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read config file failed, %w", err)
+	}
+	content := make(map[string]interface{})
+	if strings.ToLower(filepath.Ext(file)) == ".toml" {
+		err = toml.Unmarshal(data, &content)
+	} else {
+		err = yaml.Unmarshal(data, &content)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse config file %v failed, %w", file, err)
+	}
+
+	profiles, ok := content["profiles"].(map[string]interface{})
+	if content["profiles"] != nil && !ok {
+		return nil, fmt.Errorf("profiles of config file %v is not a map", file)
+	}
+	if profile == "" {
+		profile, _ = content["profile"].(string)
+	}
+	values := make(map[string]interface{})
+	for key, value := range content {
+		if key != "profiles" && key != "profile" {
+			values[key] = value
+		}
+	}
+	if profile == "" {
+		return values, nil
+	}
+
+	selected, ok := profiles[profile].(map[string]interface{})
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("profile %v not found in config file %v, available: %v",
+			profile, file, strings.Join(names, ", "))
+	}
+	for key, value := range selected {
+		values[key] = value
+	}
+	return values, nil
+}
+
+func lookupFlag(flags []cli.Flag, name string) cli.Flag {
+	for _, f := range flags {
+		for _, n := range f.Names() {
+			if n == name {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// flagIsSet reports whether the flag is given by any of its names.
+func flagIsSet(ctx *cli.Context, f cli.Flag) bool {
+	for _, name := range f.Names() {
+		if ctx.IsSet(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// optionFlags are the flags accepting a map in the config file
+var optionFlags = map[string]bool{"format_config": true}
+
+// setFlag sets the flag to a value of the config file, lists are only
+// accepted by the flags which can be repeated, and maps by optionFlags which
+// are set as key=value pairs.
+func setFlag(ctx *cli.Context, f cli.Flag, value interface{}) error {
+	name := f.Names()[0]
+	if m, ok := value.(map[string]interface{}); ok {
+		if !optionFlags[name] {
+			return errors.New("a map is given to a flag not configured by key=value pairs")
+		}
+		pairs := url.Values{}
+		for k, v := range m {
+			pairs.Set(k, fmt.Sprint(v))
+		}
+		return ctx.Set(name, pairs.Encode())
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		if _, isSlice := f.(*cli.StringSliceFlag); isSlice {
+			list = []interface{}{value}
+		} else {
+			return ctx.Set(name, fmt.Sprint(value))
+		}
+	}
+	if _, isSlice := f.(*cli.StringSliceFlag); !isSlice {
+		return errors.New("a list is given to a single value")
+	}
+	for _, v := range list {
+		if err := ctx.Set(name, fmt.Sprint(v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
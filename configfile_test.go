@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+type testFlags struct {
+	host, database, formatType, formatConfig string
+	port                                     uint
+	tables                                   cli.StringSlice
+}
+
+func runWithConfigFile(t *testing.T, file, profile string, args ...string) (*testFlags, error) {
+	var flags testFlags
+	app := cli.NewApp()
+	app.Flags = []cli.Flag{
+		&cli.StringFlag{Name: "host", Aliases: []string{"h"}, Value: "127.0.0.1", Destination: &flags.host},
+		&cli.UintFlag{Name: "port", Value: 3306, Destination: &flags.port},
+		&cli.StringFlag{Name: "database", Aliases: []string{"D"}, Destination: &flags.database},
+		&cli.StringSliceFlag{Name: "tables", Destination: &flags.tables},
+		&cli.StringFlag{Name: "format_type", Value: "json", Destination: &flags.formatType},
+		&cli.StringFlag{Name: "format_config", Destination: &flags.formatConfig},
+	}
+	app.Before = func(ctx *cli.Context) error {
+		return applyConfigFile(ctx, file, profile)
+	}
+	app.Action = func(*cli.Context) error { return nil }
+	err := app.Run(append([]string{"dbdump"}, args...))
+	return &flags, err
+}
+
+func TestApplyConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dbdump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	yamlFile := filepath.Join(dir, "dbdump.yaml")
+	if err := ioutil.WriteFile(yamlFile, []byte(`
+database: shop
+format_config:
+  lang: en
+profile: dev
+profiles:
+  dev:
+    host: dev.example.com
+  prod:
+    host: prod.example.com
+    port: 3307
+    tables: [users, orders]
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	flags, err := runWithConfigFile(t, yamlFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flags.host != "dev.example.com" || flags.database != "shop" || flags.port != 3306 || flags.formatConfig != "lang=en" {
+		t.Errorf("unexpected flags %+v", flags)
+	}
+
+	// the command line overrides the file, by any name of the flag
+	flags, err = runWithConfigFile(t, yamlFile, "prod", "-D", "crm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flags.host != "prod.example.com" || flags.database != "crm" || flags.port != 3307 ||
+		!reflect.DeepEqual(flags.tables.Value(), []string{"users", "orders"}) {
+		t.Errorf("unexpected flags %+v", flags)
+	}
+
+	if _, err := runWithConfigFile(t, yamlFile, "test"); err == nil {
+		t.Error("expected unknown profile")
+	}
+
+	tomlFile := filepath.Join(dir, "dbdump.toml")
+	if err := ioutil.WriteFile(tomlFile, []byte(`
+host = "toml.example.com"
+user = "root"
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runWithConfigFile(t, tomlFile, ""); err == nil || err.Error() != "unknown key user in config file "+tomlFile {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// maps are only accepted by format_config of the option-style formatters
+	for content, expected := range map[string]string{
+		"host:\n  name: db\n": "invalid host in config file %v, a map is given to a flag not configured by key=value pairs",
+		"format_type: gotext\nformat_config:\n  lang: en\n": "invalid format_config in config file %v, " +
+			"the gotext format takes a template instead of a map",
+	} {
+		if err := ioutil.WriteFile(yamlFile, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := runWithConfigFile(t, yamlFile, ""); err == nil || err.Error() != fmt.Sprintf(expected, yamlFile) {
+			t.Errorf("unexpected error %v", err)
+		}
+	}
+	// a template given on the command line overrides the map
+	if err := ioutil.WriteFile(yamlFile, []byte("format_type: gotext\nformat_config:\n  lang: en\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if flags, err := runWithConfigFile(t, yamlFile, "", "--format_config", "{{.}}"); err != nil || flags.formatConfig != "{{.}}" {
+		t.Errorf("unexpected flags %+v, %v", flags, err)
+	}
+}
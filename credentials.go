@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// resolveCredentials fills the connection settings of cfg which are not given
+// by flags, DBDUMP_* environment variables or the config file: from ~/.my.cnf
+// for mysql, from PGPASSWORD and ~/.pgpass for pgsql, and at last the password
+// is prompted for if stdin is a terminal.
+func resolveCredentials(cfg *config) error {
+	if cfg.Given == nil {
+		cfg.Given = make(map[string]bool)
+	}
+	switch cfg.DBType {
+	case "mysql":
+		if err := applyMyCnf(cfg); err != nil {
+			return err
+		}
+	case "pgsql":
+		if !cfg.Given["password"] {
+			password, ok, err := pgPassword(cfg)
+			if err != nil {
+				return err
+			}
+			if ok {
+				cfg.Password = password
+				cfg.Given["password"] = true
+			}
+		}
+	default:
+		return nil
+	}
+
+	if cfg.Given["password"] || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Enter password for %v@%v: ", cfg.User, cfg.Host)
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return fmt.Errorf("read password failed, %w", err)
+	}
+	cfg.Password = string(password)
+	cfg.Given["password"] = true
+	return nil
+}
+
+// applyMyCnf sets the user, password, host, port and socket not given from the
+// [client] and [dbdump] groups of ~/.my.cnf, or the password from MYSQL_PWD.
+func applyMyCnf(cfg *config) error {
+	values := make(map[string]string)
+	if home, err := os.UserHomeDir(); err == nil {
+		file := filepath.Join(home, ".my.cnf")
+		data, err := ioutil.ReadFile(file)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("read %v failed, %w", file, err)
+		}
+		values = parseMyCnf(string(data), "client", "dbdump")
+	}
+	if password, ok := os.LookupEnv("MYSQL_PWD"); ok && values["password"] == "" {
+		values["password"] = password
+	}
+
+	for _, key := range []string{"user", "password", "host", "port", "socket"} {
+		value, ok := values[key]
+		if !ok || cfg.Given[key] {
+			continue
+		}
+		switch key {
+		case "user":
+			cfg.User = value
+		case "password":
+			cfg.Password = value
+		case "host":
+			cfg.Host = value
+		case "port":
+			port, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return fmt.Errorf("invalid port %q in .my.cnf", value)
+			}
+			cfg.Port = uint(port)
+		case "socket":
+			cfg.Socket = value
+		}
+		cfg.Given[key] = true
+	}
+	return nil
+}
+
+// parseMyCnf returns the options of the groups in the option file, the options
+// of later groups override the earlier ones.
+func parseMyCnf(content string, groups ...string) map[string]string {
+	byGroup := make(map[string]map[string]string)
+	group := ""
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';' || line[0] == '!':
+			continue
+		case line[0] == '[' && strings.HasSuffix(line, "]"):
+			group = strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			continue
+		}
+		key, value := line, ""
+		if i := strings.IndexByte(line, '='); i >= 0 {
+			key, value = strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		}
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		} else if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		// dashes and underscores are interchangeable in option names
+		key = strings.ReplaceAll(strings.ToLower(key), "-", "_")
+		if byGroup[group] == nil {
+			byGroup[group] = make(map[string]string)
+		}
+		byGroup[group][key] = value
+	}
+
+	result := make(map[string]string)
+	for _, g := range groups {
+		for key, value := range byGroup[g] {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// pgPassword returns PGPASSWORD, or the password of the first matching line
+// of the password file, PGPASSFILE or ~/.pgpass, as libpq does.
+func pgPassword(cfg *config) (string, bool, error) {
+	if password, ok := os.LookupEnv("PGPASSWORD"); ok {
+		return password, true, nil
+	}
+
+	file := os.Getenv("PGPASSFILE")
+	if file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", false, nil
+		}
+		file = filepath.Join(home, ".pgpass")
+	}
+	info, err := os.Stat(file)
+	if os.IsNotExist(err) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, fmt.Errorf("read %v failed, %w", file, err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		fmt.Fprintf(os.Stderr, "WARNING: password file %v has group or world access, permissions should be u=rw (0600) or less\n", file)
+		return "", false, nil
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", false, fmt.Errorf("read %v failed, %w", file, err)
+	}
+
+	host, port := cfg.Host, cfg.Port
+	if cfg.Socket != "" {
+		// libpq matches socket connections as localhost
+		host = "localhost"
+		_, port = pgsqlSocket(cfg.Socket, cfg.Port)
+	}
+	want := []string{host, strconv.FormatUint(uint64(port), 10), cfg.Database, cfg.User}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := splitPgpass(line)
+		if len(fields) != 5 {
+			continue
+		}
+		matched := true
+		for i, field := range fields[:4] {
+			if field != "*" && field != want[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return fields[4], true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// splitPgpass splits a line of the password file by colons, \: and \\ escape
+// a colon and a backslash.
+func splitPgpass(line string) []string {
+	var fields []string
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line):
+			i++
+			b.WriteByte(line[i])
+		case line[i] == ':':
+			fields = append(fields, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(line[i])
+		}
+	}
+	return append(fields, b.String())
+}
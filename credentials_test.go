@@ -0,0 +1,80 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseMyCnf(t *testing.T) {
+	content := `
+# comment
+[mysqld]
+port = 3307
+
+[client]
+user = reader
+password = "p#ss word"
+port=3308 # trailing comment
+
+[dbdump]
+user = dumper
+skip-ssl
+`
+	want := map[string]string{
+		"user":     "dumper",
+		"password": "p#ss word",
+		"port":     "3308",
+		"skip_ssl": "",
+	}
+	if got := parseMyCnf(content, "client", "dbdump"); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMyCnf() = %v, want %v", got, want)
+	}
+}
+
+func TestPgPassword(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dbdump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "pgpass")
+	content := "# comment\n" +
+		"db.example.com:5432:shop:admin:first\n" +
+		"localhost:*:*:reader:a\\:b\\\\c\n" +
+		"*:*:*:*:fallback\n"
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Unsetenv("PGPASSWORD")
+	os.Setenv("PGPASSFILE", file)
+	defer os.Unsetenv("PGPASSFILE")
+
+	tests := []struct {
+		host, user string
+		want       string
+	}{
+		{"db.example.com", "admin", "first"},
+		{"localhost", "reader", `a:b\c`},
+		// hosts are matched literally as libpq does
+		{"127.0.0.1", "reader", "fallback"},
+		{"db.example.com", "reader", "fallback"},
+	}
+	for _, tt := range tests {
+		cfg := &config{Host: tt.host, Port: 5432, User: tt.user, Database: "shop"}
+		got, ok, err := pgPassword(cfg)
+		if err != nil || !ok || got != tt.want {
+			t.Errorf("pgPassword(%v@%v) = %q, %v, %v, want %q", tt.user, tt.host, got, ok, err, tt.want)
+		}
+	}
+
+	// 其他用户可读的密码文件被忽略
+	if err := os.Chmod(file, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := pgPassword(&config{Host: "db.example.com", Port: 5432, User: "admin", Database: "shop"}); ok {
+		t.Errorf("pgPassword() read a world readable file")
+	}
+}
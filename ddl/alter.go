@@ -0,0 +1,55 @@
+package ddl
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+// Error define
+var (
+	ErrAlterNotSupported = errors.New("does not support altering tables")
+)
+
+// AlterDialect renders the statements altering existing tables, it is
+// implemented by the dialects supporting migrations.
+type AlterDialect interface {
+	Dialect
+
+	// AlterColumn renders the statements changing column from into column to.
+	AlterColumn(table string, from, to *model.Column) []string
+	// DropConstraint renders the statement dropping constraint c.
+	DropConstraint(table string, c *model.Constraint) string
+	// DropIndex renders the statement dropping index idx of the table, schema
+	// is empty if names are not qualified.
+	DropIndex(schema, table string, idx *model.Index) string
+	// AlterTableComment renders the statements setting the comment of t.
+	AlterTableComment(table string, t *model.Table) []string
+	// Transactional reports whether DDL statements can be rolled back.
+	Transactional() bool
+}
+
+// Alter returns the dialect as AlterDialect.
+func (g *Generator) Alter() (AlterDialect, error) {
+	alter, ok := g.Dialect.(AlterDialect)
+	if !ok {
+		return nil, fmt.Errorf("dialect %w", ErrAlterNotSupported)
+	}
+	return alter, nil
+}
+
+// AddColumn renders the ALTER TABLE statement adding column c to t.
+func (g *Generator) AddColumn(t *model.Table, c *model.Column) string {
+	return "ALTER TABLE " + g.TableName(t.TableSchema, t.TableName) + " ADD COLUMN " + g.ColumnDefinition(c) + ";"
+}
+
+// DropColumn renders the ALTER TABLE statement dropping column c from t.
+func (g *Generator) DropColumn(t *model.Table, c *model.Column) string {
+	return "ALTER TABLE " + g.TableName(t.TableSchema, t.TableName) + " DROP COLUMN " + g.Quote(c.ColumnName) + ";"
+}
+
+// DropTable renders the DROP TABLE statement of t.
+func (g *Generator) DropTable(t *model.Table) string {
+	return "DROP TABLE " + g.TableName(t.TableSchema, t.TableName) + ";"
+}
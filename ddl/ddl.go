@@ -0,0 +1,427 @@
+// Package ddl renders the schema model as DDL statements of a database dialect.
+package ddl
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+// Error define
+var (
+	ErrDialectNotFound = errors.New("dialect not found")
+)
+
+var (
+	dialects = make(map[string]func(source string) Dialect)
+)
+
+// Dialect renders the engine specific parts of DDL statements.
+type Dialect interface {
+	// Quote quotes an identifier.
+	Quote(name string) string
+	// Literal quotes a string literal.
+	Literal(s string) string
+	// ColumnDefinition renders a column of CREATE TABLE, name included.
+	ColumnDefinition(c *model.Column) string
+	// TableOptions renders the options following the closing parenthesis of CREATE TABLE.
+	TableOptions(t *model.Table) string
+	// CreateIndex renders the CREATE INDEX statement of idx, table is the quoted table name.
+	CreateIndex(table string, idx *model.Index) string
+	// Comments renders the statements setting comments of the table, its columns and indexes,
+	// for engines which do not support inline comments. schema is empty if names are not qualified.
+	Comments(schema string, t *model.Table) []string
+	// AlterForeignKey reports whether foreign keys can be added by ALTER TABLE.
+	AlterForeignKey() bool
+}
+
+// RegisterDialect register a dialect, create receives the db type the
+// rendered tables are loaded from.
+func RegisterDialect(name string, create func(source string) Dialect) {
+	dialects[name] = create
+}
+
+// NewDialect creates a dialect by name, rendering tables loaded from source
+func NewDialect(name, source string) (Dialect, error) {
+	create := dialects[name]
+	if create == nil {
+		return nil, fmt.Errorf("%v %w", name, ErrDialectNotFound)
+	}
+	if source == "" {
+		source = name
+	}
+	return create(source), nil
+}
+
+// AllDialect returns all name of registered dialect, sorted
+func AllDialect() []string {
+	names := make([]string, 0, len(dialects))
+	for n := range dialects {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Options controls the generated statements.
+type Options struct {
+	// Qualify prefixes table names with their schema. Tables referenced in
+	// another schema are always qualified.
+	Qualify bool
+	// Source is the db type the tables are loaded from, which decides how
+	// column defaults are read, default the dialect.
+	Source string
+}
+
+// Generator generates DDL statements of a dialect.
+type Generator struct {
+	Dialect
+	Options
+}
+
+// NewGenerator creates a generator for the dialect
+func NewGenerator(dialect string, opts Options) (*Generator, error) {
+	d, err := NewDialect(dialect, opts.Source)
+	if err != nil {
+		return nil, err
+	}
+	return &Generator{Dialect: d, Options: opts}, nil
+}
+
+// TableName returns the quoted name of the table.
+func (g *Generator) TableName(schema, table string) string {
+	if g.Qualify && schema != "" {
+		return g.Quote(schema) + "." + g.Quote(table)
+	}
+	return g.Quote(table)
+}
+
+// Qualifier returns the schema qualifying names, or empty.
+func (g *Generator) Qualifier(schema string) string {
+	if g.Qualify {
+		return schema
+	}
+	return ""
+}
+
+// referencedTableName returns the quoted name of the table referenced by fk.
+func (g *Generator) referencedTableName(fk *model.Constraint) string {
+	if fk.ReferencedTableSchema != "" && fk.ReferencedTableSchema != fk.TableSchema {
+		return g.Quote(fk.ReferencedTableSchema) + "." + g.Quote(fk.ReferencedTableName)
+	}
+	return g.TableName(fk.TableSchema, fk.ReferencedTableName)
+}
+
+// ColumnList returns the quoted and comma separated columns.
+func (g *Generator) ColumnList(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = g.Quote(c)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// ConstraintDefinition renders a constraint of CREATE TABLE or ALTER TABLE ADD.
+func (g *Generator) ConstraintDefinition(c *model.Constraint) string {
+	var b strings.Builder
+	if c.ConstraintName != "" && c.ConstraintName != "PRIMARY" &&
+		!strings.HasPrefix(c.ConstraintName, "sqlite_autoindex_") {
+		b.WriteString("CONSTRAINT " + g.Quote(c.ConstraintName) + " ")
+	}
+	b.WriteString(c.ConstraintType + " (" + g.ColumnList(c.Columns) + ")")
+	if c.ConstraintType == model.ForeignKey {
+		b.WriteString(" REFERENCES " + g.referencedTableName(c) + " (" + g.ColumnList(c.ReferencedColumns) + ")")
+		if c.MatchOption == "FULL" || c.MatchOption == "PARTIAL" {
+			b.WriteString(" MATCH " + c.MatchOption)
+		}
+		if c.UpdateRule != "" && c.UpdateRule != "NO ACTION" {
+			b.WriteString(" ON UPDATE " + c.UpdateRule)
+		}
+		if c.DeleteRule != "" && c.DeleteRule != "NO ACTION" {
+			b.WriteString(" ON DELETE " + c.DeleteRule)
+		}
+	}
+	return b.String()
+}
+
+// indexColumnList renders the key parts of an index.
+func indexColumnList(d Dialect, idx *model.Index) string {
+	parts := make([]string, len(idx.Columns))
+	for i, c := range idx.Columns {
+		if c.Expression != "" {
+			parts[i] = "(" + c.Expression + ")"
+		} else {
+			parts[i] = d.Quote(c.ColumnName)
+			// prefix lengths are mysql only, the others index the whole value
+			if _, ok := d.(mysqlDialect); ok && c.SubPart > 0 {
+				parts[i] += fmt.Sprintf("(%d)", c.SubPart)
+			}
+		}
+		if c.Order == "DESC" {
+			parts[i] += " DESC"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// CreateTable renders the CREATE TABLE statement of t. The foreign keys in
+// skip are left out, they are added later by ALTER TABLE.
+func (g *Generator) CreateTable(t *model.Table, skip map[*model.Constraint]bool) string {
+	var lines []string
+	for _, c := range t.Columns {
+		lines = append(lines, "  "+g.ColumnDefinition(c))
+	}
+	for _, c := range sortedConstraints(t.Constraints) {
+		if skip[c] || g.inlinePrimaryKey(t, c) {
+			continue
+		}
+		lines = append(lines, "  "+g.ConstraintDefinition(c))
+	}
+	return "CREATE TABLE " + g.TableName(t.TableSchema, t.TableName) + " (\n" +
+		strings.Join(lines, ",\n") + "\n)" + g.TableOptions(t) + ";"
+}
+
+// AddConstraint renders the ALTER TABLE statement adding c.
+func (g *Generator) AddConstraint(c *model.Constraint) string {
+	return "ALTER TABLE " + g.TableName(c.TableSchema, c.TableName) + " ADD " + g.ConstraintDefinition(c) + ";"
+}
+
+// CreateIndexes renders the CREATE INDEX statements of t, indexes which
+// implement a constraint are created with the constraint and left out.
+func (g *Generator) CreateIndexes(t *model.Table) []string {
+	var result []string
+	for _, idx := range t.Indexes {
+		if idx.Primary || t.Constraint(idx.IndexName) != nil {
+			continue
+		}
+		if hasUnknownExpression(idx) {
+			result = append(result, fmt.Sprintf("-- index %v skipped, its expression is unknown", idx.IndexName))
+			continue
+		}
+		result = append(result, g.CreateIndex(g.TableName(t.TableSchema, t.TableName), idx))
+	}
+	return result
+}
+
+// Generate renders tables as CREATE TABLE, CREATE INDEX and COMMENT statements.
+//
+// Tables are created after the tables they reference, foreign keys of
+// circular references are added by ALTER TABLE once all tables exist.
+// Views are skipped since their definitions are not loaded.
+func (g *Generator) Generate(tables map[string][]*model.Table) []string {
+	ordered, deferred := sortTables(tables, g.AlterForeignKey())
+
+	var result []string
+	for _, t := range ordered {
+		result = append(result, g.CreateTable(t, deferred))
+		result = append(result, g.CreateIndexes(t)...)
+		result = append(result, g.Comments(g.Qualifier(t.TableSchema), t)...)
+	}
+	for _, t := range ordered {
+		for _, c := range sortedConstraints(t.Constraints) {
+			if deferred[c] {
+				result = append(result, g.AddConstraint(c))
+			}
+		}
+	}
+	return result
+}
+
+// sortTables orders tables so that every table follows the tables it
+// references. Foreign keys closing a cycle are returned as deferred when
+// alter is true, otherwise they are kept inline and the cycle is ignored.
+func sortTables(tables map[string][]*model.Table, alter bool) ([]*model.Table, map[*model.Constraint]bool) {
+	schemas := make([]string, 0, len(tables))
+	for schema := range tables {
+		schemas = append(schemas, schema)
+	}
+	sort.Strings(schemas)
+
+	type key struct{ schema, table string }
+	byKey := make(map[key]*model.Table)
+	var all []*model.Table
+	for _, schema := range schemas {
+		sorted := make([]*model.Table, 0, len(tables[schema]))
+		for _, t := range tables[schema] {
+			if !t.IsView() {
+				sorted = append(sorted, t)
+			}
+		}
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].TableName < sorted[j].TableName })
+		for _, t := range sorted {
+			byKey[key{schema, t.TableName}] = t
+		}
+		all = append(all, sorted...)
+	}
+
+	const visiting, visited = 1, 2
+	state := make(map[*model.Table]int)
+	deferred := make(map[*model.Constraint]bool)
+	var ordered []*model.Table
+	var visit func(t *model.Table)
+	visit = func(t *model.Table) {
+		state[t] = visiting
+		for _, fk := range sortedConstraints(t.ForeignKeys()) {
+			schema := fk.ReferencedTableSchema
+			if schema == "" {
+				schema = t.TableSchema
+			}
+			target := byKey[key{schema, fk.ReferencedTableName}]
+			if target == nil || target == t {
+				continue
+			}
+			switch state[target] {
+			case visiting:
+				if alter {
+					deferred[fk] = true
+				}
+			case 0:
+				visit(target)
+			}
+		}
+		state[t] = visited
+		ordered = append(ordered, t)
+	}
+	for _, t := range all {
+		if state[t] == 0 {
+			visit(t)
+		}
+	}
+	return ordered, deferred
+}
+
+// constraintOrder orders constraints of CREATE TABLE by type
+var constraintOrder = map[string]int{
+	model.PrimaryKey: 0,
+	model.Unique:     1,
+	model.ForeignKey: 2,
+}
+
+// sortedConstraints returns the constraints ordered by type and name.
+func sortedConstraints(constraints []*model.Constraint) []*model.Constraint {
+	sorted := make([]*model.Constraint, len(constraints))
+	copy(sorted, constraints)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		oi, oj := constraintOrder[sorted[i].ConstraintType], constraintOrder[sorted[j].ConstraintType]
+		if oi != oj {
+			return oi < oj
+		}
+		return sorted[i].ConstraintName < sorted[j].ConstraintName
+	})
+	return sorted
+}
+
+// inlinePrimaryKey reports whether c is the primary key of an autoincrement
+// sqlite column, which is declared in the column definition.
+func (g *Generator) inlinePrimaryKey(t *model.Table, c *model.Constraint) bool {
+	if _, ok := g.Dialect.(sqliteDialect); !ok {
+		return false
+	}
+	if c.ConstraintType != model.PrimaryKey || len(c.Columns) != 1 {
+		return false
+	}
+	column := t.Column(c.Columns[0])
+	return column != nil && autoIncrement(column)
+}
+
+func hasUnknownExpression(idx *model.Index) bool {
+	for _, c := range idx.Columns {
+		if c.Expression == model.UnknownExpression {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultKeywords are defaults which must not be quoted
+var defaultKeywords = map[string]bool{
+	"NULL":              true,
+	"TRUE":              true,
+	"FALSE":             true,
+	"CURRENT_TIMESTAMP": true,
+	"CURRENT_DATE":      true,
+	"CURRENT_TIME":      true,
+	"LOCALTIME":         true,
+	"LOCALTIMESTAMP":    true,
+}
+
+// mysqlNumericTypes are the types whose defaults mysql reports as numbers
+var mysqlNumericTypes = map[string]bool{
+	"tinyint": true, "smallint": true, "mediumint": true, "int": true, "integer": true, "bigint": true,
+	"decimal": true, "numeric": true, "float": true, "double": true, "real": true, "year": true,
+	"bool": true, "boolean": true,
+}
+
+// mysqlTemporalTypes are the types which default to CURRENT_TIMESTAMP
+// without DEFAULT_GENERATED before MySQL 8.0
+var mysqlTemporalTypes = map[string]bool{
+	"timestamp": true, "datetime": true, "date": true, "time": true,
+}
+
+var (
+	// mysqlCurrentTimestamp matches CURRENT_TIMESTAMP and CURRENT_TIMESTAMP(3)
+	mysqlCurrentTimestamp = regexp.MustCompile(`^(?i)CURRENT_TIMESTAMP(\(\d*\))?$`)
+	// mysqlBitLiteral matches the bit defaults, e.g. b'101'
+	mysqlBitLiteral = regexp.MustCompile(`^b'[01]*'$`)
+	// pgsqlCast matches a literal cast to its type, e.g. 'x'::character varying
+	pgsqlCast = regexp.MustCompile(`^('(?:[^']|'')*'|-?[0-9.]+)(::[a-z_ ]+(\([0-9, ]*\))?(\[\])?)+$`)
+)
+
+// DefaultValue renders the default of c, which is read the way the source db
+// type reports it. MySQL reports string defaults unquoted and marks the
+// expressions by DEFAULT_GENERATED in EXTRA, except CURRENT_TIMESTAMP before
+// 8.0. Pgsql and sqlite report defaults as SQL expressions, the casts pgsql
+// adds to literals are removed for the other dialects.
+func DefaultValue(d Dialect, source string, c *model.Column) string {
+	v := c.ColumnDefault
+	if source != "mysql" {
+		if _, ok := d.(pgsqlDialect); !ok && source == "pgsql" {
+			if m := pgsqlCast.FindStringSubmatch(v); m != nil {
+				return m[1]
+			}
+		}
+		return v
+	}
+
+	typ := baseType(c)
+	switch {
+	case strings.Contains(c.Extra, "DEFAULT_GENERATED"),
+		mysqlTemporalTypes[typ] && mysqlCurrentTimestamp.MatchString(v),
+		typ == "bit" && mysqlBitLiteral.MatchString(v):
+		return v
+	}
+	if _, err := strconv.ParseFloat(v, 64); err == nil && mysqlNumericTypes[typ] {
+		return v
+	}
+	return d.Literal(v)
+}
+
+// autoIncrement reports whether c takes its values from a sequence, either by
+// EXTRA or by a pgsql serial default
+func autoIncrement(c *model.Column) bool {
+	return c.Extra == "auto_increment" || c.Extra == "autoincrement" || sequenceDefault(c)
+}
+
+// sequenceDefault reports whether c defaults to nextval() of a pgsql sequence,
+// e.g. a serial column. The sequence itself is not dumped, so the default is
+// rendered as the autoincrement of the dialect.
+func sequenceDefault(c *model.Column) bool {
+	return !c.ColumnDefaultNull && strings.HasPrefix(strings.ToLower(c.ColumnDefault), "nextval(")
+}
+
+// baseType returns the lower case type of c without length or modifiers
+func baseType(c *model.Column) string {
+	typ := c.DataType
+	if typ == "" {
+		typ = c.ColumnType
+	}
+	if i := strings.IndexAny(typ, "( "); i >= 0 {
+		typ = typ[:i]
+	}
+	return strings.ToLower(typ)
+}
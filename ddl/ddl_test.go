@@ -0,0 +1,400 @@
+package ddl
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Nutao/dbdump/model"
+	"github.com/Nutao/dbdump/parser"
+)
+
+func testTables() map[string][]*model.Table {
+	users := &model.Table{
+		TableSchema:  "shop",
+		TableName:    "users",
+		TableComment: "registered users",
+		Columns: []*model.Column{
+			{ColumnName: "id", ColumnType: "int", IsNullable: "NO", ColumnDefaultNull: true, Extra: "auto_increment"},
+			{ColumnName: "name", ColumnType: "varchar(32)", IsNullable: "YES", ColumnDefault: "it's", ColumnComment: "nick"},
+			{ColumnName: "last_order", ColumnType: "int", IsNullable: "YES", ColumnDefaultNull: true},
+		},
+		Constraints: []*model.Constraint{
+			{ConstraintName: "fk_last_order", ConstraintType: model.ForeignKey, TableSchema: "shop", TableName: "users",
+				Columns: []string{"last_order"}, ReferencedTableName: "orders", ReferencedColumns: []string{"id"}},
+			{ConstraintName: "PRIMARY", ConstraintType: model.PrimaryKey, TableSchema: "shop", TableName: "users",
+				Columns: []string{"id"}},
+		},
+		Indexes: []*model.Index{
+			{IndexName: "PRIMARY", Primary: true, Unique: true, Columns: []*model.IndexColumn{{ColumnName: "id"}}},
+			{IndexName: "fk_last_order", Columns: []*model.IndexColumn{{ColumnName: "last_order"}}},
+			{IndexName: "idx_name", IndexType: "BTREE", Visible: true,
+				Columns: []*model.IndexColumn{{ColumnName: "name", SubPart: 8, Order: "DESC"}}},
+		},
+	}
+	orders := &model.Table{
+		TableSchema: "shop",
+		TableName:   "orders",
+		Columns: []*model.Column{
+			{ColumnName: "id", ColumnType: "int", IsNullable: "NO", ColumnDefaultNull: true},
+			{ColumnName: "user_id", ColumnType: "int", IsNullable: "NO", ColumnDefaultNull: true},
+			{ColumnName: "created", ColumnType: "timestamp", IsNullable: "NO", ColumnDefault: "CURRENT_TIMESTAMP",
+				Extra: "DEFAULT_GENERATED on update CURRENT_TIMESTAMP"},
+		},
+		Constraints: []*model.Constraint{
+			{ConstraintName: "PRIMARY", ConstraintType: model.PrimaryKey, TableSchema: "shop", TableName: "orders",
+				Columns: []string{"id"}},
+			{ConstraintName: "fk_user", ConstraintType: model.ForeignKey, TableSchema: "shop", TableName: "orders",
+				Columns: []string{"user_id"}, ReferencedTableName: "users", ReferencedColumns: []string{"id"},
+				DeleteRule: "CASCADE", UpdateRule: "NO ACTION"},
+		},
+	}
+	items := &model.Table{
+		TableSchema: "shop",
+		TableName:   "items",
+		Columns: []*model.Column{
+			{ColumnName: "order_id", ColumnType: "int", IsNullable: "NO", ColumnDefaultNull: true},
+		},
+		Constraints: []*model.Constraint{
+			{ConstraintName: "fk_order", ConstraintType: model.ForeignKey, TableSchema: "shop", TableName: "items",
+				Columns: []string{"order_id"}, ReferencedTableName: "orders", ReferencedColumns: []string{"id"}},
+		},
+	}
+	return map[string][]*model.Table{"shop": {users, orders, items}}
+}
+
+func TestGenerateMySQL(t *testing.T) {
+	g, err := NewGenerator("mysql", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"CREATE TABLE `users` (\n" +
+			"  `id` int NOT NULL AUTO_INCREMENT,\n" +
+			"  `name` varchar(32) NULL DEFAULT 'it''s' COMMENT 'nick',\n" +
+			"  `last_order` int NULL,\n" +
+			"  PRIMARY KEY (`id`)\n" +
+			") COMMENT='registered users';",
+		"CREATE INDEX `idx_name` ON `users` (`name`(8) DESC);",
+		"CREATE TABLE `orders` (\n" +
+			"  `id` int NOT NULL,\n" +
+			"  `user_id` int NOT NULL,\n" +
+			"  `created` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n" +
+			"  PRIMARY KEY (`id`),\n" +
+			"  CONSTRAINT `fk_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE\n" +
+			");",
+		"CREATE TABLE `items` (\n" +
+			"  `order_id` int NOT NULL,\n" +
+			"  CONSTRAINT `fk_order` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`)\n" +
+			");",
+		"ALTER TABLE `users` ADD CONSTRAINT `fk_last_order` FOREIGN KEY (`last_order`) REFERENCES `orders` (`id`);",
+	}
+	if got := g.Generate(testTables()); !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected statements\n%q", got)
+	}
+}
+
+func TestGeneratePGSQL(t *testing.T) {
+	g, err := NewGenerator("pgsql", Options{Qualify: true, Source: "mysql"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`CREATE TABLE "shop"."users" (
+  "id" int NOT NULL GENERATED BY DEFAULT AS IDENTITY,
+  "name" varchar(32) DEFAULT 'it''s',
+  "last_order" int,
+  PRIMARY KEY ("id")
+);`,
+		`CREATE INDEX "idx_name" ON "shop"."users" ("name" DESC);`,
+		`COMMENT ON TABLE "shop"."users" IS 'registered users';`,
+		`COMMENT ON COLUMN "shop"."users"."name" IS 'nick';`,
+		`CREATE TABLE "shop"."orders" (
+  "id" int NOT NULL,
+  "user_id" int NOT NULL,
+  "created" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_user" FOREIGN KEY ("user_id") REFERENCES "shop"."users" ("id") ON DELETE CASCADE
+);`,
+		`CREATE TABLE "shop"."items" (
+  "order_id" int NOT NULL,
+  CONSTRAINT "fk_order" FOREIGN KEY ("order_id") REFERENCES "shop"."orders" ("id")
+);`,
+		`ALTER TABLE "shop"."users" ADD CONSTRAINT "fk_last_order" FOREIGN KEY ("last_order") REFERENCES "shop"."orders" ("id");`,
+	}
+	if got := g.Generate(testTables()); !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected statements\n%v", strings.Join(got, "\n"))
+	}
+}
+
+// TestGenerateAutoIncrement checks that only sqlite declares the primary key
+// of an autoincrement column inline.
+func TestGenerateAutoIncrement(t *testing.T) {
+	tables := map[string][]*model.Table{"main": {{
+		TableSchema: "main",
+		TableName:   "notes",
+		Columns: []*model.Column{
+			{ColumnName: "id", DataType: "INTEGER", ColumnType: "INTEGER", IsNullable: "NO", ColumnDefaultNull: true,
+				Extra: "autoincrement"},
+			{ColumnName: "body", DataType: "TEXT", ColumnType: "TEXT", IsNullable: "YES", ColumnDefault: "''"},
+		},
+		Constraints: []*model.Constraint{
+			{ConstraintName: "PRIMARY", ConstraintType: model.PrimaryKey, TableSchema: "main", TableName: "notes",
+				Columns: []string{"id"}},
+		},
+	}}}
+	tests := []struct {
+		dialect  string
+		expected string
+	}{
+		{"sqlite", `CREATE TABLE "notes" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "body" TEXT DEFAULT ''
+);`},
+		{"mysql", "CREATE TABLE `notes` (\n" +
+			"  `id` bigint NOT NULL AUTO_INCREMENT,\n" +
+			"  `body` TEXT NULL DEFAULT '',\n" +
+			"  PRIMARY KEY (`id`)\n" +
+			");"},
+		{"pgsql", `CREATE TABLE "notes" (
+  "id" bigint NOT NULL GENERATED BY DEFAULT AS IDENTITY,
+  "body" TEXT DEFAULT '',
+  PRIMARY KEY ("id")
+);`},
+	}
+	for _, tt := range tests {
+		g, err := NewGenerator(tt.dialect, Options{Source: "sqlite"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := g.Generate(tables); !reflect.DeepEqual(got, []string{tt.expected}) {
+			t.Errorf("%v: unexpected statements\n%v", tt.dialect, strings.Join(got, "\n"))
+		}
+	}
+}
+
+func TestDefaultValue(t *testing.T) {
+	tests := []struct {
+		dialect, source string
+		column          model.Column
+		expected        string
+	}{
+		{"mysql", "mysql", model.Column{ColumnType: "varchar(32)", ColumnDefault: "a (b)"}, "'a (b)'"},
+		{"mysql", "mysql", model.Column{ColumnType: "varchar(32)", ColumnDefault: "x::y"}, "'x::y'"},
+		{"pgsql", "mysql", model.Column{ColumnType: "varchar(32)", ColumnDefault: "f(x)"}, "'f(x)'"},
+		{"mysql", "mysql", model.Column{ColumnType: "varchar(32)", ColumnDefault: "CURRENT_TIMESTAMP"}, "'CURRENT_TIMESTAMP'"},
+		{"mysql", "mysql", model.Column{ColumnType: "varchar(8)", ColumnDefault: "1e5"}, "'1e5'"},
+		{"mysql", "mysql", model.Column{ColumnType: "int", ColumnDefault: "-1"}, "-1"},
+		{"mysql", "mysql", model.Column{ColumnType: "bit(3)", ColumnDefault: "b'101'"}, "b'101'"},
+		{"mysql", "mysql", model.Column{ColumnType: "datetime(3)", ColumnDefault: "CURRENT_TIMESTAMP(3)"}, "CURRENT_TIMESTAMP(3)"},
+		{"mysql", "mysql", model.Column{DataType: "json", ColumnType: "json", ColumnDefault: "json_array()",
+			Extra: "DEFAULT_GENERATED"}, "json_array()"},
+		{"pgsql", "pgsql", model.Column{ColumnType: "varchar(32)", ColumnDefault: "'a (b)'::character varying"}, "'a (b)'::character varying"},
+		{"mysql", "pgsql", model.Column{ColumnType: "varchar(32)", ColumnDefault: "'a (b)'::character varying"}, "'a (b)'"},
+		{"sqlite", "pgsql", model.Column{ColumnType: "numeric", ColumnDefault: "0::numeric"}, "0"},
+		{"mysql", "pgsql", model.Column{ColumnType: "timestamptz", ColumnDefault: "now()"}, "now()"},
+		{"pgsql", "sqlite", model.Column{ColumnType: "TEXT", ColumnDefault: "'it''s'"}, "'it''s'"},
+	}
+	for _, tt := range tests {
+		d, err := NewDialect(tt.dialect, tt.source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := DefaultValue(d, tt.source, &tt.column); got != tt.expected {
+			t.Errorf("DefaultValue(%v from %v, %q) = %q, want %q", tt.dialect, tt.source, tt.column.ColumnDefault, got, tt.expected)
+		}
+	}
+}
+
+// pgDump is the schema of a serial table as written by pg_dump
+const pgDump = `
+CREATE TABLE public.users (
+    id integer NOT NULL,
+    name character varying(32) DEFAULT 'x'::character varying NOT NULL
+);
+
+CREATE SEQUENCE public.users_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE public.users_id_seq OWNED BY public.users.id;
+
+ALTER TABLE ONLY public.users ALTER COLUMN id SET DEFAULT nextval('public.users_id_seq'::regclass);
+
+ALTER TABLE ONLY public.users
+    ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+`
+
+func TestGenerateSequenceDefault(t *testing.T) {
+	p, err := parser.NewParser("pgsql", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Parse(pgDump); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		dialect  string
+		expected string
+	}{
+		{"pgsql", `CREATE TABLE "users" (
+  "id" int4 NOT NULL GENERATED BY DEFAULT AS IDENTITY,
+  "name" varchar(32) NOT NULL DEFAULT 'x'::character varying,
+  CONSTRAINT "users_pkey" PRIMARY KEY ("id")
+);`},
+		{"mysql", "CREATE TABLE `users` (\n" +
+			"  `id` int NOT NULL AUTO_INCREMENT,\n" +
+			"  `name` varchar(32) NOT NULL DEFAULT 'x',\n" +
+			"  CONSTRAINT `users_pkey` PRIMARY KEY (`id`)\n" +
+			");"},
+		{"sqlite", `CREATE TABLE "users" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "name" text NOT NULL DEFAULT 'x'
+);`},
+	}
+	for _, tt := range tests {
+		g, err := NewGenerator(tt.dialect, Options{Source: "pgsql"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := g.Generate(p.Tables())
+		if !reflect.DeepEqual(got, []string{tt.expected}) {
+			t.Errorf("%v: unexpected statements\n%v", tt.dialect, strings.Join(got, "\n"))
+		}
+	}
+}
+
+// TestGenerateCrossDialect checks that the source types are mapped to types
+// the target accepts.
+func TestGenerateCrossDialect(t *testing.T) {
+	mysqlTables := map[string][]*model.Table{"shop": {{
+		TableSchema: "shop",
+		TableName:   "posts",
+		Columns: []*model.Column{
+			{ColumnName: "id", DataType: "int", ColumnType: "int(10) unsigned", IsNullable: "NO",
+				ColumnDefaultNull: true, Extra: "auto_increment"},
+			{ColumnName: "published", DataType: "tinyint", ColumnType: "tinyint(1)", IsNullable: "NO",
+				ColumnDefault: "0"},
+			{ColumnName: "created_at", DataType: "datetime", ColumnType: "datetime", IsNullable: "YES",
+				ColumnDefaultNull: true},
+			{ColumnName: "body", DataType: "longtext", ColumnType: "longtext", IsNullable: "YES",
+				ColumnDefaultNull: true},
+		},
+		Constraints: []*model.Constraint{
+			{ConstraintName: "PRIMARY", ConstraintType: model.PrimaryKey, TableSchema: "shop", TableName: "posts",
+				Columns: []string{"id"}},
+		},
+	}}}
+	pgsqlTables := map[string][]*model.Table{"public": {{
+		TableSchema: "public",
+		TableName:   "events",
+		Columns: []*model.Column{
+			{ColumnName: "id", DataType: "int4", ColumnType: "serial", IsNullable: "N",
+				ColumnDefault: "nextval('events_id_seq'::regclass)"},
+			{ColumnName: "payload", DataType: "jsonb", ColumnType: "jsonb", IsNullable: "Y", ColumnDefaultNull: true},
+			{ColumnName: "happened_at", DataType: "timestamptz", ColumnType: "timestamptz", IsNullable: "N",
+				ColumnDefaultNull: true},
+		},
+		Constraints: []*model.Constraint{
+			{ConstraintName: "events_pkey", ConstraintType: model.PrimaryKey, TableSchema: "public",
+				TableName: "events", Columns: []string{"id"}},
+		},
+	}}}
+	tests := []struct {
+		source   string
+		dialect  string
+		expected string
+	}{
+		{"mysql", "pgsql", `CREATE TABLE "posts" (
+  "id" bigint NOT NULL GENERATED BY DEFAULT AS IDENTITY,
+  "published" boolean NOT NULL DEFAULT false,
+  "created_at" timestamp,
+  "body" text,
+  PRIMARY KEY ("id")
+);`},
+		{"mysql", "sqlite", `CREATE TABLE "posts" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "published" integer NOT NULL DEFAULT 0,
+  "created_at" datetime,
+  "body" text
+);`},
+		{"pgsql", "mysql", "CREATE TABLE `events` (\n" +
+			"  `id` int NOT NULL AUTO_INCREMENT,\n" +
+			"  `payload` json NULL,\n" +
+			"  `happened_at` timestamp NOT NULL,\n" +
+			"  CONSTRAINT `events_pkey` PRIMARY KEY (`id`)\n" +
+			");"},
+		{"pgsql", "sqlite", `CREATE TABLE "events" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "payload" text,
+  "happened_at" datetime NOT NULL
+);`},
+	}
+	for _, tt := range tests {
+		tables := mysqlTables
+		if tt.source == "pgsql" {
+			tables = pgsqlTables
+		}
+		g, err := NewGenerator(tt.dialect, Options{Source: tt.source})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := g.Generate(tables); !reflect.DeepEqual(got, []string{tt.expected}) {
+			t.Errorf("%v to %v: unexpected statements\n%v", tt.source, tt.dialect, strings.Join(got, "\n"))
+		}
+	}
+}
+
+func TestAlterSequenceDefault(t *testing.T) {
+	serial := &model.Column{ColumnName: "id", ColumnType: "int4", IsNullable: "NO",
+		ColumnDefault: "nextval('users_id_seq'::regclass)"}
+	renamed := &model.Column{ColumnName: "id", ColumnType: "int4", IsNullable: "NO",
+		ColumnDefault: "nextval('accounts_id_seq'::regclass)"}
+	plain := &model.Column{ColumnName: "id", ColumnType: "int4", IsNullable: "NO", ColumnDefault: "0"}
+	tests := []struct {
+		from, to *model.Column
+		expected []string
+	}{
+		{serial, renamed, nil},
+		{plain, serial, []string{
+			`ALTER TABLE "users" ALTER COLUMN "id" DROP DEFAULT;`,
+			`ALTER TABLE "users" ALTER COLUMN "id" ADD GENERATED BY DEFAULT AS IDENTITY;`,
+		}},
+		{serial, plain, []string{
+			`ALTER TABLE "users" ALTER COLUMN "id" DROP IDENTITY IF EXISTS;`,
+			`ALTER TABLE "users" ALTER COLUMN "id" SET DEFAULT 0;`,
+		}},
+	}
+	d := pgsqlDialect{source: "pgsql"}
+	for _, tt := range tests {
+		if got := d.AlterColumn(`"users"`, tt.from, tt.to); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("AlterColumn(%q, %q) = %q", tt.from.ColumnDefault, tt.to.ColumnDefault, got)
+		}
+	}
+}
+
+func TestCreatePartialIndex(t *testing.T) {
+	idx := &model.Index{IndexName: "uk_email", Unique: true, Visible: true, Predicate: "(deleted_at IS NULL)",
+		Columns: []*model.IndexColumn{{ColumnName: "email"}}}
+	tests := []struct {
+		dialect  string
+		expected string
+	}{
+		{"pgsql", `CREATE UNIQUE INDEX "uk_email" ON "users" ("email") WHERE (deleted_at IS NULL);`},
+		{"sqlite", `CREATE UNIQUE INDEX "uk_email" ON "users" ("email") WHERE (deleted_at IS NULL);`},
+		{"mysql", "-- index uk_email skipped, mysql has no partial index WHERE (deleted_at IS NULL)"},
+	}
+	for _, tt := range tests {
+		g, err := NewGenerator(tt.dialect, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := g.CreateIndex(g.Quote("users"), idx); got != tt.expected {
+			t.Errorf("%v: CreateIndex() = %q", tt.dialect, got)
+		}
+	}
+}
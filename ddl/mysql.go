@@ -0,0 +1,131 @@
+package ddl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func init() {
+	RegisterDialect("mysql", func(source string) Dialect {
+		return mysqlDialect{source: source}
+	})
+}
+
+type mysqlDialect struct {
+	source string
+}
+
+func (mysqlDialect) Quote(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (mysqlDialect) Literal(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func (d mysqlDialect) ColumnDefinition(c *model.Column) string {
+	var b strings.Builder
+	b.WriteString(d.Quote(c.ColumnName) + " " + columnType("mysql", d.source, c))
+	if c.Nullable() {
+		b.WriteString(" NULL")
+	} else {
+		b.WriteString(" NOT NULL")
+	}
+
+	// EXTRA is e.g. "auto_increment" or "DEFAULT_GENERATED on update CURRENT_TIMESTAMP",
+	// DEFAULT_GENERATED marks an expression default of MySQL 8.0
+	extra := strings.TrimSpace(strings.Replace(c.Extra, "DEFAULT_GENERATED", "", 1))
+	if !c.ColumnDefaultNull && !sequenceDefault(c) {
+		value := DefaultValue(d, d.source, c)
+		if strings.Contains(c.Extra, "DEFAULT_GENERATED") && !strings.HasPrefix(value, "(") &&
+			!defaultKeywords[strings.ToUpper(value)] && !strings.HasPrefix(strings.ToUpper(value), "CURRENT_") {
+			value = "(" + value + ")"
+		}
+		b.WriteString(" DEFAULT " + value)
+	}
+	// generated columns can not be rendered without their expression
+	if extra == "autoincrement" || sequenceDefault(c) {
+		b.WriteString(" AUTO_INCREMENT")
+	} else if extra != "" && !strings.Contains(extra, "GENERATED") {
+		b.WriteString(" " + strings.ToUpper(extra))
+	}
+	if c.ColumnComment != "" {
+		b.WriteString(" COMMENT " + d.Literal(c.ColumnComment))
+	}
+	return b.String()
+}
+
+func (d mysqlDialect) TableOptions(t *model.Table) string {
+	if t.TableComment != "" {
+		return " COMMENT=" + d.Literal(t.TableComment)
+	}
+	return ""
+}
+
+// CreateIndex renders CREATE INDEX, a partial index is skipped since a full
+// index, unique in particular, would be stricter than the source.
+func (d mysqlDialect) CreateIndex(table string, idx *model.Index) string {
+	if idx.Predicate != "" {
+		return fmt.Sprintf("-- index %v skipped, mysql has no partial index WHERE %v", idx.IndexName, idx.Predicate)
+	}
+	var b strings.Builder
+	b.WriteString("CREATE ")
+	switch {
+	case idx.IndexType == "FULLTEXT" || idx.IndexType == "SPATIAL":
+		b.WriteString(idx.IndexType + " ")
+	case idx.Unique:
+		b.WriteString("UNIQUE ")
+	}
+	b.WriteString("INDEX " + d.Quote(idx.IndexName) + " ON " + table + " (" + indexColumnList(d, idx) + ")")
+	if idx.IndexType == "HASH" {
+		b.WriteString(" USING HASH")
+	}
+	if idx.Comment != "" {
+		b.WriteString(" COMMENT " + d.Literal(idx.Comment))
+	}
+	if !idx.Visible {
+		b.WriteString(" INVISIBLE")
+	}
+	return b.String() + ";"
+}
+
+// Comments returns nothing, mysql comments are declared inline.
+func (mysqlDialect) Comments(string, *model.Table) []string {
+	return nil
+}
+
+func (mysqlDialect) AlterForeignKey() bool {
+	return true
+}
+
+// AlterColumn renders a single MODIFY COLUMN, which redefines the whole column.
+func (d mysqlDialect) AlterColumn(table string, from, to *model.Column) []string {
+	return []string{"ALTER TABLE " + table + " MODIFY COLUMN " + d.ColumnDefinition(to) + ";"}
+}
+
+func (d mysqlDialect) DropConstraint(table string, c *model.Constraint) string {
+	switch c.ConstraintType {
+	case model.PrimaryKey:
+		return "ALTER TABLE " + table + " DROP PRIMARY KEY;"
+	case model.ForeignKey:
+		return "ALTER TABLE " + table + " DROP FOREIGN KEY " + d.Quote(c.ConstraintName) + ";"
+	}
+	// unique constraints are unique indexes
+	return "ALTER TABLE " + table + " DROP INDEX " + d.Quote(c.ConstraintName) + ";"
+}
+
+func (d mysqlDialect) DropIndex(schema, table string, idx *model.Index) string {
+	return "DROP INDEX " + d.Quote(idx.IndexName) + " ON " + table + ";"
+}
+
+func (d mysqlDialect) AlterTableComment(table string, t *model.Table) []string {
+	return []string{"ALTER TABLE " + table + " COMMENT=" + d.Literal(t.TableComment) + ";"}
+}
+
+// Transactional returns false, DDL statements cause an implicit commit.
+func (mysqlDialect) Transactional() bool {
+	return false
+}
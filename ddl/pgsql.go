@@ -0,0 +1,186 @@
+package ddl
+
+import (
+	"strings"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func init() {
+	RegisterDialect("pgsql", func(source string) Dialect {
+		return pgsqlDialect{source: source}
+	})
+}
+
+type pgsqlDialect struct {
+	source string
+}
+
+func (pgsqlDialect) Quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (pgsqlDialect) Literal(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func (d pgsqlDialect) ColumnDefinition(c *model.Column) string {
+	typ := d.columnType(c)
+	def := d.Quote(c.ColumnName) + " " + typ
+	if !c.Nullable() {
+		def += " NOT NULL"
+	}
+	if autoIncrement(c) {
+		def += " GENERATED BY DEFAULT AS IDENTITY"
+	} else if !c.ColumnDefaultNull && c.ColumnDefault != "" {
+		def += " DEFAULT " + d.defaultValue(typ, c)
+	}
+	return def
+}
+
+// columnType maps the type of a column of another database type, an
+// identity column must be an integer so bigint unsigned becomes bigint.
+func (d pgsqlDialect) columnType(c *model.Column) string {
+	typ := columnType("pgsql", d.source, c)
+	if autoIncrement(c) && d.source != "pgsql" && strings.HasPrefix(typ, "numeric") {
+		return "bigint"
+	}
+	return typ
+}
+
+// defaultValue renders the default of c, a mysql tinyint(1) default 0 or 1 is
+// false or true as the column is mapped to boolean.
+func (d pgsqlDialect) defaultValue(typ string, c *model.Column) string {
+	if typ == "boolean" && d.source == "mysql" {
+		switch c.ColumnDefault {
+		case "0":
+			return "false"
+		case "1":
+			return "true"
+		}
+	}
+	return DefaultValue(d, d.source, c)
+}
+
+func (pgsqlDialect) TableOptions(*model.Table) string {
+	return ""
+}
+
+func (d pgsqlDialect) CreateIndex(table string, idx *model.Index) string {
+	var b strings.Builder
+	b.WriteString("CREATE ")
+	if idx.Unique {
+		b.WriteString("UNIQUE ")
+	}
+	b.WriteString("INDEX " + d.Quote(idx.IndexName) + " ON " + table)
+	if idx.IndexType != "" && idx.IndexType != "BTREE" {
+		b.WriteString(" USING " + strings.ToLower(idx.IndexType))
+	}
+	b.WriteString(" (" + indexColumnList(d, idx) + ")")
+	if idx.Predicate != "" {
+		b.WriteString(" WHERE " + idx.Predicate)
+	}
+	return b.String() + ";"
+}
+
+// Comments renders COMMENT ON statements.
+func (d pgsqlDialect) Comments(schema string, t *model.Table) []string {
+	prefix := ""
+	if schema != "" {
+		prefix = d.Quote(schema) + "."
+	}
+	table := prefix + d.Quote(t.TableName)
+
+	var result []string
+	if t.TableComment != "" {
+		result = append(result, "COMMENT ON TABLE "+table+" IS "+d.Literal(t.TableComment)+";")
+	}
+	for _, c := range t.Columns {
+		if c.ColumnComment != "" {
+			result = append(result, "COMMENT ON COLUMN "+table+"."+d.Quote(c.ColumnName)+" IS "+
+				d.Literal(c.ColumnComment)+";")
+		}
+	}
+	for _, idx := range t.Indexes {
+		if idx.Comment != "" {
+			result = append(result, "COMMENT ON INDEX "+prefix+d.Quote(idx.IndexName)+" IS "+
+				d.Literal(idx.Comment)+";")
+		}
+	}
+	return result
+}
+
+func (pgsqlDialect) AlterForeignKey() bool {
+	return true
+}
+
+// AlterColumn renders one ALTER COLUMN per changed attribute.
+func (d pgsqlDialect) AlterColumn(table string, from, to *model.Column) []string {
+	var result []string
+	alter := "ALTER TABLE " + table + " ALTER COLUMN " + d.Quote(to.ColumnName)
+	if typ := d.columnType(to); !strings.EqualFold(from.Type(), typ) {
+		result = append(result, alter+" TYPE "+typ+";")
+	}
+	if from.Nullable() != to.Nullable() {
+		if to.Nullable() {
+			result = append(result, alter+" DROP NOT NULL;")
+		} else {
+			result = append(result, alter+" SET NOT NULL;")
+		}
+	}
+	switch {
+	case autoIncrement(from) && autoIncrement(to):
+		// both take their values from a sequence
+	case autoIncrement(to):
+		if !from.ColumnDefaultNull {
+			result = append(result, alter+" DROP DEFAULT;")
+		}
+		result = append(result, alter+" ADD GENERATED BY DEFAULT AS IDENTITY;")
+	case autoIncrement(from):
+		result = append(result, alter+" DROP IDENTITY IF EXISTS;")
+		if !to.ColumnDefaultNull {
+			result = append(result, alter+" SET DEFAULT "+d.defaultValue(d.columnType(to), to)+";")
+		} else if !from.ColumnDefaultNull {
+			result = append(result, alter+" DROP DEFAULT;")
+		}
+	case from.ColumnDefaultNull != to.ColumnDefaultNull || from.ColumnDefault != to.ColumnDefault:
+		if to.ColumnDefaultNull {
+			result = append(result, alter+" DROP DEFAULT;")
+		} else {
+			result = append(result, alter+" SET DEFAULT "+d.defaultValue(d.columnType(to), to)+";")
+		}
+	}
+	if from.ColumnComment != to.ColumnComment {
+		result = append(result, "COMMENT ON COLUMN "+table+"."+d.Quote(to.ColumnName)+" IS "+
+			d.commentLiteral(to.ColumnComment)+";")
+	}
+	return result
+}
+
+func (d pgsqlDialect) DropConstraint(table string, c *model.Constraint) string {
+	return "ALTER TABLE " + table + " DROP CONSTRAINT " + d.Quote(c.ConstraintName) + ";"
+}
+
+// DropIndex renders DROP INDEX, pgsql index names are unique in the schema.
+func (d pgsqlDialect) DropIndex(schema, table string, idx *model.Index) string {
+	if schema != "" {
+		return "DROP INDEX " + d.Quote(schema) + "." + d.Quote(idx.IndexName) + ";"
+	}
+	return "DROP INDEX " + d.Quote(idx.IndexName) + ";"
+}
+
+func (d pgsqlDialect) AlterTableComment(table string, t *model.Table) []string {
+	return []string{"COMMENT ON TABLE " + table + " IS " + d.commentLiteral(t.TableComment) + ";"}
+}
+
+func (pgsqlDialect) Transactional() bool {
+	return true
+}
+
+// commentLiteral returns NULL for an empty comment, which removes the comment.
+func (d pgsqlDialect) commentLiteral(comment string) string {
+	if comment == "" {
+		return "NULL"
+	}
+	return d.Literal(comment)
+}
@@ -0,0 +1,73 @@
+package ddl
+
+import (
+	"strings"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func init() {
+	RegisterDialect("sqlite", func(source string) Dialect {
+		return sqliteDialect{source: source}
+	})
+}
+
+type sqliteDialect struct {
+	source string
+}
+
+func (sqliteDialect) Quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (sqliteDialect) Literal(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// ColumnDefinition declares an autoincrement column, also a mysql
+// auto_increment or a pgsql serial one, as INTEGER PRIMARY KEY AUTOINCREMENT,
+// which sqlite only accepts inline. sqlite has no sequences, so a serial
+// column gets the values of the rowid.
+func (d sqliteDialect) ColumnDefinition(c *model.Column) string {
+	if autoIncrement(c) {
+		// AUTOINCREMENT is only accepted on a column typed exactly INTEGER
+		return d.Quote(c.ColumnName) + " INTEGER PRIMARY KEY AUTOINCREMENT"
+	}
+	def := d.Quote(c.ColumnName) + " " + columnType("sqlite", d.source, c)
+	if !c.Nullable() {
+		def += " NOT NULL"
+	}
+	if !c.ColumnDefaultNull && c.ColumnDefault != "" {
+		def += " DEFAULT " + DefaultValue(d, d.source, c)
+	}
+	return def
+}
+
+func (sqliteDialect) TableOptions(*model.Table) string {
+	return ""
+}
+
+func (d sqliteDialect) CreateIndex(table string, idx *model.Index) string {
+	var b strings.Builder
+	b.WriteString("CREATE ")
+	if idx.Unique {
+		b.WriteString("UNIQUE ")
+	}
+	b.WriteString("INDEX " + d.Quote(idx.IndexName) + " ON " + table + " (" + indexColumnList(d, idx) + ")")
+	if idx.Predicate != "" {
+		b.WriteString(" WHERE " + idx.Predicate)
+	}
+	return b.String() + ";"
+}
+
+// Comments returns nothing, sqlite has no comments.
+func (sqliteDialect) Comments(string, *model.Table) []string {
+	return nil
+}
+
+// AlterForeignKey returns false, sqlite can not add constraints to an
+// existing table. Foreign keys are not checked on CREATE TABLE, so they are
+// declared inline even if the referenced table is created later.
+func (sqliteDialect) AlterForeignKey() bool {
+	return false
+}
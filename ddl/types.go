@@ -0,0 +1,112 @@
+package ddl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+// sqlTypes are the types of the dialects by the Go type of the column, see
+// model.Column.GoType.
+var sqlTypes = map[string]map[string]string{
+	"mysql": {
+		"bool": "tinyint(1)", "int8": "tinyint", "uint8": "tinyint unsigned", "int16": "smallint",
+		"uint16": "smallint unsigned", "int32": "int", "uint32": "int unsigned", "int64": "bigint",
+		"uint64": "bigint unsigned", "float32": "float", "float64": "double", "time.Time": "datetime",
+		"[]byte": "blob", "string": "text",
+	},
+	"pgsql": {
+		"bool": "boolean", "int8": "smallint", "uint8": "smallint", "int16": "smallint", "uint16": "integer",
+		"int32": "integer", "uint32": "bigint", "int64": "bigint", "uint64": "numeric(20)", "float32": "real",
+		"float64": "double precision", "time.Time": "timestamp", "[]byte": "bytea", "string": "text",
+	},
+	"sqlite": {
+		"bool": "integer", "int8": "integer", "uint8": "integer", "int16": "integer", "uint16": "integer",
+		"int32": "integer", "uint32": "integer", "int64": "integer", "uint64": "integer", "float32": "real",
+		"float64": "real", "time.Time": "datetime", "[]byte": "blob", "string": "text",
+	},
+}
+
+// nativeTypes are the data types kept as they are in the dialects.
+var nativeTypes = map[string]string{
+	"mysql": " tinyint smallint mediumint int bigint decimal float double bit date datetime timestamp time year" +
+		" char varchar binary varbinary tinyblob blob mediumblob longblob tinytext text mediumtext longtext enum" +
+		" set json geometry point linestring polygon ",
+	"pgsql": " int2 int4 int8 numeric decimal float4 float8 real bool boolean varchar" +
+		" bpchar char text bytea date timestamp timestamptz time timetz interval json jsonb uuid inet cidr" +
+		" macaddr xml money bit varbit tsvector ",
+	"sqlite": " integer real text blob numeric ",
+}
+
+// SQLType returns the type of the column in the dialect, the column type is
+// kept if its data type is native to the dialect. Integer names such as
+// bigint are not native to pgsql, because mysql and sqlite report them with
+// display widths or unsigned.
+func SQLType(dialect string, c *model.Column) (string, error) {
+	types := sqlTypes[dialect]
+	if types == nil {
+		return "", fmt.Errorf("unsupported dialect %q", dialect)
+	}
+	dataType := strings.ToLower(c.DataType)
+	if strings.Contains(nativeTypes[dialect], " "+dataType+" ") ||
+		dialect == "pgsql" && strings.HasPrefix(dataType, "_") {
+		return c.Type(), nil
+	}
+
+	switch dataType {
+	case "decimal", "numeric":
+		// keep the precision and scale
+		args := ""
+		if i := strings.IndexByte(c.ColumnType, '('); i >= 0 {
+			args = c.ColumnType[i:]
+			if j := strings.IndexByte(args, ')'); j >= 0 {
+				args = args[:j+1]
+			}
+		}
+		if dialect == "mysql" {
+			return "decimal" + args, nil
+		}
+		return "numeric" + args, nil
+	case "date":
+		return "date", nil
+	case "timestamptz", "timestamp with time zone":
+		if dialect == "mysql" {
+			return "timestamp", nil
+		}
+	case "json", "jsonb":
+		switch dialect {
+		case "mysql":
+			return "json", nil
+		case "pgsql":
+			return "jsonb", nil
+		}
+	case "uuid":
+		if dialect == "mysql" {
+			return "char(36)", nil
+		}
+	}
+	if n := c.MaxLength(); n > 0 && dialect != "sqlite" {
+		if strings.Contains(strings.ToLower(c.DataType), "var") {
+			return fmt.Sprintf("varchar(%d)", n), nil
+		}
+		return fmt.Sprintf("char(%d)", n), nil
+	}
+	if t := types[c.GoType()]; t != "" {
+		return t, nil
+	}
+	return c.Type(), nil
+}
+
+// columnType returns the type of c in the dialect, the type is mapped by
+// SQLType if c is loaded from another database type.
+func columnType(dialect, source string, c *model.Column) string {
+	if source == dialect {
+		return c.Type()
+	}
+	t, err := SQLType(dialect, c)
+	if err != nil {
+		return c.Type()
+	}
+	return t
+}
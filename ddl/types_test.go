@@ -0,0 +1,32 @@
+package ddl
+
+import (
+	"testing"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func TestSQLType(t *testing.T) {
+	for _, test := range []struct {
+		dialect  string
+		column   model.Column
+		expected string
+	}{
+		{"pgsql", model.Column{DataType: "int", ColumnType: "int(10) unsigned"}, "bigint"},
+		{"pgsql", model.Column{DataType: "int", ColumnType: "int(11)"}, "integer"},
+		{"pgsql", model.Column{DataType: "datetime", ColumnType: "datetime(3)"}, "timestamp"},
+		{"pgsql", model.Column{DataType: "tinyint", ColumnType: "tinyint(1)"}, "boolean"},
+		{"pgsql", model.Column{DataType: "decimal", ColumnType: "decimal(10,2)"}, "decimal(10,2)"},
+		{"mysql", model.Column{DataType: "numeric", ColumnType: "numeric(10,2)"}, "decimal(10,2)"},
+		{"pgsql", model.Column{DataType: "varchar", ColumnType: "varchar(64)"}, "varchar(64)"},
+		{"mysql", model.Column{DataType: "bpchar", ColumnType: "bpchar(2)"}, "char(2)"},
+		{"mysql", model.Column{DataType: "jsonb", ColumnType: "jsonb"}, "json"},
+		{"mysql", model.Column{DataType: "timestamptz", ColumnType: "timestamptz"}, "timestamp"},
+		{"mysql", model.Column{DataType: "int4", ColumnType: "int4"}, "int"},
+		{"sqlite", model.Column{DataType: "varchar", ColumnType: "varchar(64)"}, "text"},
+	} {
+		if s, err := SQLType(test.dialect, &test.column); err != nil || s != test.expected {
+			t.Errorf("SQLType(%v, %v) = %v, %v, expected %v", test.dialect, test.column.ColumnType, s, err, test.expected)
+		}
+	}
+}
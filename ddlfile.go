@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Nutao/dbdump/model"
+	"github.com/Nutao/dbdump/parser"
+)
+
+// loadDDLFiles parses the DDL files of cfg with the dialect of its db type,
+// only the given tables are kept if any.
+func loadDDLFiles(cfg *config) (map[string][]*model.Table, error) {
+	// mysql tables belong to the database, pgsql tables to the public schema
+	schema := cfg.Database
+	if cfg.DBType == "pgsql" {
+		schema = "public"
+	}
+	p, err := parser.NewParser(cfg.DBType, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := ddlFiles(cfg.DDLFiles)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read ddl file failed, %w", err)
+		}
+		if err := p.Parse(string(data)); err != nil {
+			return nil, fmt.Errorf("parse %v failed, %w", file, err)
+		}
+	}
+	return filterTables(p.Tables(), cfg.Tables), nil
+}
+
+// ddlFiles expands directories to the .sql files in them, ordered by name as
+// numbered migrations are.
+func ddlFiles(paths []string) ([]string, error) {
+	var result []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("read ddl file failed, %w", err)
+		}
+		if !info.IsDir() {
+			result = append(result, path)
+			continue
+		}
+
+		// ReadDir returns the entries sorted by name
+		entries, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("read ddl directory failed, %w", err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), ".sql") {
+				result = append(result, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,605 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Nutao/dbdump/model"
+	"github.com/urfave/cli/v2"
+)
+
+// diffExitCode is the exit code of the diff command when the schemas differ,
+// errors exit with 1.
+const diffExitCode = 2
+
+// Kinds of change
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// SchemaDiff holds the differences between two sets of tables, as produced
+// by the introspectors.
+type SchemaDiff struct {
+	Tables []*TableDiff
+}
+
+// TableDiff holds the differences of a table. From is nil for added tables
+// and To is nil for removed tables.
+type TableDiff struct {
+	TableSchema string
+	TableName   string
+	Change      string
+	Fields      []string // changed table attributes, e.g. comment
+
+	Columns     []*ColumnDiff
+	Constraints []*ConstraintDiff
+	Indexes     []*IndexDiff
+
+	From *model.Table `json:"-"`
+	To   *model.Table `json:"-"`
+}
+
+// ColumnDiff holds the differences of a column.
+type ColumnDiff struct {
+	ColumnName string
+	Change     string
+	Fields     []string      // type, nullable, default, comment or extra
+	From       *model.Column `json:",omitempty"`
+	To         *model.Column `json:",omitempty"`
+}
+
+// ConstraintDiff holds the differences of a constraint.
+type ConstraintDiff struct {
+	ConstraintName string
+	Change         string
+	From           *model.Constraint `json:",omitempty"`
+	To             *model.Constraint `json:",omitempty"`
+}
+
+// IndexDiff holds the differences of an index.
+type IndexDiff struct {
+	IndexName string
+	Change    string
+	From      *model.Index `json:",omitempty"`
+	To        *model.Index `json:",omitempty"`
+}
+
+// Empty reports whether there is no difference.
+func (d *SchemaDiff) Empty() bool {
+	return len(d.Tables) == 0
+}
+
+// compareSchemas compares the tables from with the tables to, changes are
+// reported as what has to be done to from to get to.
+//
+// Tables are matched by schema and name. If both sides hold a single schema,
+// e.g. the same database deployed under different names, the schemas are
+// matched regardless of their names and reported by the name of from.
+func compareSchemas(from, to map[string][]*model.Table) *SchemaDiff {
+	pairs := make(map[string][2][]*model.Table)
+	if len(from) == 1 && len(to) == 1 {
+		for schema, fromTables := range from {
+			for _, toTables := range to {
+				pairs[schema] = [2][]*model.Table{fromTables, toTables}
+			}
+		}
+	} else {
+		for schema, tables := range from {
+			p := pairs[schema]
+			p[0] = tables
+			pairs[schema] = p
+		}
+		for schema, tables := range to {
+			p := pairs[schema]
+			p[1] = tables
+			pairs[schema] = p
+		}
+	}
+
+	result := &SchemaDiff{}
+	for _, schema := range sortedKeys(pairs) {
+		fromTables := make(map[string]*model.Table)
+		for _, t := range pairs[schema][0] {
+			fromTables[t.TableName] = t
+		}
+		toTables := make(map[string]*model.Table)
+		for _, t := range pairs[schema][1] {
+			toTables[t.TableName] = t
+		}
+
+		for _, name := range unionKeys(fromTables, toTables) {
+			if d := compareTables(fromTables[name], toTables[name]); d != nil {
+				d.TableSchema = schema
+				result.Tables = append(result.Tables, d)
+			}
+		}
+	}
+	return result
+}
+
+// compareTables returns nil if the tables are identical.
+func compareTables(from, to *model.Table) *TableDiff {
+	switch {
+	case from == nil:
+		return &TableDiff{TableName: to.TableName, Change: Added, To: to}
+	case to == nil:
+		return &TableDiff{TableName: from.TableName, Change: Removed, From: from}
+	}
+
+	d := &TableDiff{TableName: to.TableName, Change: Changed, From: from, To: to}
+	if from.TableComment != to.TableComment {
+		d.Fields = append(d.Fields, "comment")
+	}
+
+	fromColumns := make(map[string]*model.Column)
+	for _, c := range from.Columns {
+		fromColumns[c.ColumnName] = c
+	}
+	// columns are reported in the order of to, removed ones last
+	for _, c := range to.Columns {
+		if cd := compareColumns(fromColumns[c.ColumnName], c); cd != nil {
+			d.Columns = append(d.Columns, cd)
+		}
+	}
+	for _, c := range from.Columns {
+		if to.Column(c.ColumnName) == nil {
+			d.Columns = append(d.Columns, &ColumnDiff{ColumnName: c.ColumnName, Change: Removed, From: c})
+		}
+	}
+
+	fromConstraints, toConstraints := constraintsByKey(from), constraintsByKey(to)
+	for _, key := range unionKeys(fromConstraints, toConstraints) {
+		f, t := fromConstraints[key], toConstraints[key]
+		switch {
+		case f == nil:
+			d.Constraints = append(d.Constraints, &ConstraintDiff{ConstraintName: t.ConstraintName, Change: Added, To: t})
+		case t == nil:
+			d.Constraints = append(d.Constraints, &ConstraintDiff{ConstraintName: f.ConstraintName, Change: Removed, From: f})
+		case !sameConstraint(f, t):
+			d.Constraints = append(d.Constraints, &ConstraintDiff{ConstraintName: t.ConstraintName, Change: Changed,
+				From: f, To: t})
+		}
+	}
+
+	fromIndexes, toIndexes := indexesByName(from), indexesByName(to)
+	for _, name := range unionKeys(fromIndexes, toIndexes) {
+		f, t := fromIndexes[name], toIndexes[name]
+		switch {
+		case f == nil:
+			d.Indexes = append(d.Indexes, &IndexDiff{IndexName: name, Change: Added, To: t})
+		case t == nil:
+			d.Indexes = append(d.Indexes, &IndexDiff{IndexName: name, Change: Removed, From: f})
+		case indexSignature(f) != indexSignature(t):
+			d.Indexes = append(d.Indexes, &IndexDiff{IndexName: name, Change: Changed, From: f, To: t})
+		}
+	}
+
+	if len(d.Fields) == 0 && len(d.Columns) == 0 && len(d.Constraints) == 0 && len(d.Indexes) == 0 {
+		return nil
+	}
+	return d
+}
+
+// compareColumns returns nil if the columns are identical.
+func compareColumns(from, to *model.Column) *ColumnDiff {
+	if from == nil {
+		return &ColumnDiff{ColumnName: to.ColumnName, Change: Added, To: to}
+	}
+
+	var fields []string
+	if !strings.EqualFold(from.Type(), to.Type()) {
+		fields = append(fields, "type")
+	}
+	if from.Nullable() != to.Nullable() {
+		fields = append(fields, "nullable")
+	}
+	if from.ColumnDefaultNull != to.ColumnDefaultNull || from.ColumnDefault != to.ColumnDefault {
+		fields = append(fields, "default")
+	}
+	if from.ColumnComment != to.ColumnComment {
+		fields = append(fields, "comment")
+	}
+	if !strings.EqualFold(from.Extra, to.Extra) {
+		fields = append(fields, "extra")
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return &ColumnDiff{ColumnName: to.ColumnName, Change: Changed, Fields: fields, From: from, To: to}
+}
+
+// constraintsByKey keys constraints by name, except the primary key which is
+// keyed by type since engines name it differently, e.g. PRIMARY and <table>_pkey.
+func constraintsByKey(t *model.Table) map[string]*model.Constraint {
+	result := make(map[string]*model.Constraint)
+	for _, c := range t.Constraints {
+		if c.ConstraintType == model.PrimaryKey {
+			result[model.PrimaryKey] = c
+		} else {
+			result[c.ConstraintName] = c
+		}
+	}
+	return result
+}
+
+func sameConstraint(a, b *model.Constraint) bool {
+	return a.ConstraintType == b.ConstraintType &&
+		strings.Join(a.Columns, ",") == strings.Join(b.Columns, ",") &&
+		a.ReferencedTableName == b.ReferencedTableName &&
+		strings.Join(a.ReferencedColumns, ",") == strings.Join(b.ReferencedColumns, ",") &&
+		a.UpdateRule == b.UpdateRule &&
+		a.DeleteRule == b.DeleteRule
+}
+
+// indexesByName keys the indexes by name, primary key indexes are compared
+// as constraint.
+func indexesByName(t *model.Table) map[string]*model.Index {
+	result := make(map[string]*model.Index)
+	for _, idx := range t.Indexes {
+		if !idx.Primary {
+			result[idx.IndexName] = idx
+		}
+	}
+	return result
+}
+
+// indexSignature returns a string holding every attribute of the index
+// except its name, for comparison.
+func indexSignature(idx *model.Index) string {
+	parts := []string{
+		fmt.Sprint(idx.Unique), idx.IndexType, idx.Predicate, fmt.Sprint(idx.Visible),
+	}
+	for _, c := range idx.Columns {
+		parts = append(parts, fmt.Sprintf("%s|%s|%s|%d", c.ColumnName, c.Expression, c.Order, c.SubPart))
+	}
+	return strings.Join(parts, ";")
+}
+
+func sortedKeys(m map[string][2][]*model.Table) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// unionKeys returns the sorted union of the keys of two maps of the same type.
+func unionKeys(a, b interface{}) []string {
+	set := make(map[string]bool)
+	for _, m := range []interface{}{a, b} {
+		switch m := m.(type) {
+		case map[string]*model.Table:
+			for k := range m {
+				set[k] = true
+			}
+		case map[string]*model.Constraint:
+			for k := range m {
+				set[k] = true
+			}
+		case map[string]*model.Index:
+			for k := range m {
+				set[k] = true
+			}
+		}
+	}
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// String renders the differences in a human-readable form, a table line is
+// followed by the changes of its columns, constraints and indexes:
+//
+//	$ dbdump -D shop diff --target_host prod
+//	+ table   added
+//	- table   removed
+//	~ table   changed
+//	    + column   added
+func (d *SchemaDiff) String() string {
+	var b strings.Builder
+	for _, t := range d.Tables {
+		fmt.Fprintf(&b, "%s table %s.%s", changeMark(t.Change), t.TableSchema, t.TableName)
+		if t.Change == Changed && len(t.Fields) != 0 {
+			fmt.Fprintf(&b, ": comment %q -> %q", t.From.TableComment, t.To.TableComment)
+		}
+		b.WriteString("\n")
+
+		for _, c := range t.Columns {
+			fmt.Fprintf(&b, "    %s column %s", changeMark(c.Change), c.ColumnName)
+			switch c.Change {
+			case Added:
+				fmt.Fprintf(&b, " %s", describeColumn(c.To))
+			case Changed:
+				changes := make([]string, len(c.Fields))
+				for i, f := range c.Fields {
+					changes[i] = fmt.Sprintf("%s %s -> %s", f, columnField(c.From, f), columnField(c.To, f))
+				}
+				fmt.Fprintf(&b, ": %s", strings.Join(changes, ", "))
+			}
+			b.WriteString("\n")
+		}
+		for _, c := range t.Constraints {
+			fmt.Fprintf(&b, "    %s constraint %s", changeMark(c.Change), c.ConstraintName)
+			switch c.Change {
+			case Added:
+				fmt.Fprintf(&b, " %s", describeConstraint(c.To))
+			case Changed:
+				fmt.Fprintf(&b, ": %s -> %s", describeConstraint(c.From), describeConstraint(c.To))
+			}
+			b.WriteString("\n")
+		}
+		for _, idx := range t.Indexes {
+			fmt.Fprintf(&b, "    %s index %s", changeMark(idx.Change), idx.IndexName)
+			switch idx.Change {
+			case Added:
+				fmt.Fprintf(&b, " %s", describeIndex(idx.To))
+			case Changed:
+				fmt.Fprintf(&b, ": %s -> %s", describeIndex(idx.From), describeIndex(idx.To))
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func changeMark(change string) string {
+	switch change {
+	case Added:
+		return "+"
+	case Removed:
+		return "-"
+	}
+	return "~"
+}
+
+func describeColumn(c *model.Column) string {
+	return fmt.Sprintf("%s %s default %s", c.Type(), columnField(c, "nullable"), columnField(c, "default"))
+}
+
+func columnField(c *model.Column, field string) string {
+	switch field {
+	case "type":
+		return c.Type()
+	case "nullable":
+		if c.Nullable() {
+			return "NULL"
+		}
+		return "NOT NULL"
+	case "default":
+		if c.ColumnDefaultNull {
+			return "NULL"
+		}
+		return fmt.Sprintf("%q", c.ColumnDefault)
+	case "comment":
+		return fmt.Sprintf("%q", c.ColumnComment)
+	case "extra":
+		return fmt.Sprintf("%q", c.Extra)
+	}
+	return ""
+}
+
+func describeConstraint(c *model.Constraint) string {
+	desc := fmt.Sprintf("%s (%s)", c.ConstraintType, strings.Join(c.Columns, ", "))
+	if c.ConstraintType == model.ForeignKey {
+		desc += fmt.Sprintf(" REFERENCES %s (%s) ON UPDATE %s ON DELETE %s", c.ReferencedTableName,
+			strings.Join(c.ReferencedColumns, ", "), c.UpdateRule, c.DeleteRule)
+	}
+	return desc
+}
+
+func describeIndex(idx *model.Index) string {
+	parts := make([]string, len(idx.Columns))
+	for i, c := range idx.Columns {
+		parts[i] = c.ColumnName + c.Expression
+		if c.SubPart > 0 {
+			parts[i] += fmt.Sprintf("(%d)", c.SubPart)
+		}
+		if c.Order == "DESC" {
+			parts[i] += " DESC"
+		}
+	}
+	desc := fmt.Sprintf("%s (%s)", idx.IndexType, strings.Join(parts, ", "))
+	if idx.Unique {
+		desc = "UNIQUE " + desc
+	}
+	if idx.Predicate != "" {
+		desc += " WHERE " + idx.Predicate
+	}
+	if !idx.Visible {
+		desc += " INVISIBLE"
+	}
+	return desc
+}
+
+// targetFlags returns the flags describing the target database of diff and
+// migrate, target flags which are not set default to the global ones.
+func targetFlags(target *config) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "target_dsn",
+			EnvVars:     []string{"DBDUMP_TARGET_DSN"},
+			Usage:       "Connection url of the target database, overrides the other target flags.",
+			Destination: &target.DSN,
+		},
+		&cli.StringFlag{
+			Name:        "target_dbType",
+			EnvVars:     []string{"DBDUMP_TARGET_DBTYPE"},
+			Usage:       "db type of the target database.",
+			Destination: &target.DBType,
+		},
+		&cli.StringFlag{
+			Name:        "target_host",
+			EnvVars:     []string{"DBDUMP_TARGET_HOST"},
+			Usage:       "Connect to target host.",
+			Destination: &target.Host,
+		},
+		&cli.UintFlag{
+			Name:        "target_port",
+			EnvVars:     []string{"DBDUMP_TARGET_PORT"},
+			Usage:       "Port number to use for target connection.",
+			Destination: &target.Port,
+		},
+		&cli.StringFlag{
+			Name:        "target_socket",
+			EnvVars:     []string{"DBDUMP_TARGET_SOCKET"},
+			Usage:       "Unix socket to connect to the target database with.",
+			Destination: &target.Socket,
+		},
+		&cli.StringFlag{
+			Name:        "target_user",
+			EnvVars:     []string{"DBDUMP_TARGET_USER"},
+			Usage:       "User for login to target database.",
+			Destination: &target.User,
+		},
+		&cli.StringFlag{
+			Name:        "target_password",
+			EnvVars:     []string{"DBDUMP_TARGET_PASSWORD"},
+			Usage:       "Password to use when connecting to target server.",
+			Destination: &target.Password,
+		},
+		&cli.StringFlag{
+			Name:        "target_database",
+			EnvVars:     []string{"DBDUMP_TARGET_DATABASE"},
+			Usage:       "Target database to use.",
+			Destination: &target.Database,
+		},
+		&cli.StringFlag{
+			Name:        "target_snapshot",
+			Usage:       "Read target tables from a file written by the json format.",
+			Destination: &target.Snapshot,
+		},
+		&cli.StringSliceFlag{
+			Name:  "target_ddl",
+			Usage: "Parse target tables from DDL files, or directories of .sql files, of the target db type.",
+		},
+	}
+}
+
+// introspectBoth loads the tables of the database given by the global flags
+// (source) and of the target database.
+func introspectBoth(ctx *cli.Context, target *config) (source, targetTables map[string][]*model.Table, err error) {
+	if err = inheritTarget(ctx, target); err != nil {
+		return nil, nil, err
+	}
+	if source, err = introspect(gConfig); err != nil {
+		return nil, nil, fmt.Errorf("load source schema failed, %w", err)
+	}
+	if targetTables, err = introspect(target); err != nil {
+		return nil, nil, fmt.Errorf("load target schema failed, %w", err)
+	}
+	return source, targetTables, nil
+}
+
+// inheritTarget fills the target flags not given from the source connection.
+// The port, socket and password are only inherited by the same db type, a
+// target of another db type uses its default port.
+func inheritTarget(ctx *cli.Context, target *config) error {
+	if !ctx.IsSet("target_dbType") {
+		target.DBType = gConfig.DBType
+	}
+	same := target.DBType == gConfig.DBType
+	if !ctx.IsSet("target_host") {
+		target.Host = gConfig.Host
+	}
+	if !ctx.IsSet("target_port") {
+		if same {
+			target.Port = gConfig.Port
+		} else {
+			target.Port = defaultPorts[target.DBType]
+		}
+	}
+	// 指定了目标host或port时不使用源库的socket
+	if same && !ctx.IsSet("target_socket") && !ctx.IsSet("target_host") && !ctx.IsSet("target_port") {
+		target.Socket = gConfig.Socket
+	}
+	if !ctx.IsSet("target_user") {
+		target.User = gConfig.User
+	}
+	if same && !ctx.IsSet("target_password") {
+		target.Password = gConfig.Password
+	}
+	if !ctx.IsSet("target_database") {
+		target.Database = gConfig.Database
+	}
+	// 未指定的目标连接参数同样从凭据文件读取
+	target.Given = make(map[string]bool)
+	for _, name := range []string{"host", "port", "socket", "user", "password"} {
+		inherited := gConfig.Given[name]
+		if name == "port" || name == "socket" || name == "password" {
+			inherited = inherited && same
+		}
+		target.Given[name] = ctx.IsSet("target_"+name) || inherited
+	}
+	if target.DSN != "" {
+		if err := applyDSN(target); err != nil {
+			return err
+		}
+	} else if same {
+		target.Params = gConfig.Params
+	}
+	// 目标库使用相同的TLS参数
+	target.SSLMode, target.SSLCA, target.SSLServerName = gConfig.SSLMode, gConfig.SSLCA, gConfig.SSLServerName
+	target.SSLCert, target.SSLKey = gConfig.SSLCert, gConfig.SSLKey
+	target.Tables = gConfig.Tables
+	target.Schemas = gConfig.Schemas
+	target.DDLFiles = ctx.StringSlice("target_ddl")
+	return nil
+}
+
+// diffCommand compares the database given by the global flags (source) with
+// the database given by the --target_* flags.
+func diffCommand() *cli.Command {
+	target := &config{}
+	var format string
+	return &cli.Command{
+		Name:  "diff",
+		Usage: "Compare the schema of the database with a target database",
+		Flags: append(targetFlags(target),
+			&cli.StringFlag{
+				Name:        "format",
+				Usage:       "Format of the differences(text|json).",
+				Value:       "text",
+				Destination: &format,
+			},
+		),
+		Action: func(ctx *cli.Context) error {
+			return diff(ctx, target, format)
+		},
+	}
+}
+
+func diff(ctx *cli.Context, target *config, format string) error {
+	source, targetTables, err := introspectBoth(ctx, target)
+	if err != nil {
+		return err
+	}
+	d := compareSchemas(source, targetTables)
+
+	var data []byte
+	switch format {
+	case "text":
+		data = []byte(strings.TrimSuffix(d.String(), "\n"))
+	case "json":
+		if data, err = json.MarshalIndent(d, "", "  "); err != nil {
+			return fmt.Errorf("formate output failed, %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported diff format %v", format)
+	}
+	if err := writeOutput(data); err != nil {
+		return err
+	}
+
+	if !d.Empty() {
+		return cli.Exit("", diffExitCode)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Nutao/dbdump/model"
+	"github.com/urfave/cli/v2"
+)
+
+func TestCompareSchemas(t *testing.T) {
+	from := map[string][]*model.Table{
+		"staging": {
+			{TableName: "users", Columns: []*model.Column{
+				{ColumnName: "id", ColumnType: "int(11)", IsNullable: "NO", ColumnDefaultNull: true},
+				{ColumnName: "name", ColumnType: "varchar(32)", IsNullable: "YES", ColumnDefaultNull: true},
+				{ColumnName: "legacy", ColumnType: "int(11)", IsNullable: "YES", ColumnDefaultNull: true},
+			}, Constraints: []*model.Constraint{
+				{ConstraintName: "PRIMARY", ConstraintType: model.PrimaryKey, Columns: []string{"id"}},
+			}},
+			{TableName: "logs"},
+		},
+	}
+	to := map[string][]*model.Table{
+		"prod": {
+			{TableName: "users", Columns: []*model.Column{
+				{ColumnName: "id", ColumnType: "INT(11)", IsNullable: "N", ColumnDefaultNull: true},
+				{ColumnName: "name", ColumnType: "varchar(64)", IsNullable: "NO", ColumnDefault: "x"},
+				{ColumnName: "email", ColumnType: "varchar(64)", IsNullable: "YES", ColumnDefaultNull: true},
+			}, Constraints: []*model.Constraint{
+				{ConstraintName: "users_pkey", ConstraintType: model.PrimaryKey, Columns: []string{"id"}},
+				{ConstraintName: "uk_email", ConstraintType: model.Unique, Columns: []string{"email"}},
+			}},
+			{TableName: "orders"},
+		},
+	}
+
+	d := compareSchemas(from, to)
+	var summary []string
+	for _, table := range d.Tables {
+		summary = append(summary, table.Change+" "+table.TableSchema+"."+table.TableName)
+		for _, c := range table.Columns {
+			summary = append(summary, c.Change+" column "+c.ColumnName+" "+fmt.Sprint(c.Fields))
+		}
+		for _, c := range table.Constraints {
+			summary = append(summary, c.Change+" constraint "+c.ConstraintName)
+		}
+	}
+	expected := []string{
+		"removed staging.logs",
+		"added staging.orders",
+		"changed staging.users",
+		"changed column name [type nullable default]",
+		"added column email []",
+		"removed column legacy []",
+		"added constraint uk_email",
+	}
+	if !reflect.DeepEqual(summary, expected) {
+		t.Errorf("unexpected diff %q", summary)
+	}
+
+	if d := compareSchemas(from, from); !d.Empty() {
+		t.Errorf("unexpected diff of identical schemas %+v", d.Tables)
+	}
+}
+
+func TestInheritTarget(t *testing.T) {
+	saved := *gConfig
+	defer func() { *gConfig = saved }()
+	gConfig.DBType, gConfig.Host, gConfig.Port, gConfig.Socket = "mysql", "db", 3307, "/tmp/mysql.sock"
+	gConfig.User, gConfig.Password, gConfig.Database = "root", "secret", "shop"
+	gConfig.Given = map[string]bool{"host": true, "port": true, "socket": true, "user": true, "password": true}
+
+	tests := []struct {
+		args     []string
+		expected config
+	}{
+		{nil, config{DBType: "mysql", Host: "db", Port: 3307, Socket: "/tmp/mysql.sock", User: "root",
+			Password: "secret", Database: "shop"}},
+		{[]string{"--target_port", "3308"}, config{DBType: "mysql", Host: "db", Port: 3308, User: "root",
+			Password: "secret", Database: "shop"}},
+		{[]string{"--target_dbType", "pgsql"}, config{DBType: "pgsql", Host: "db", Port: 5432, User: "root",
+			Database: "shop"}},
+	}
+	for _, tt := range tests {
+		target := &config{}
+		app := cli.NewApp()
+		app.Flags = targetFlags(target)
+		app.Action = func(ctx *cli.Context) error {
+			return inheritTarget(ctx, target)
+		}
+		if err := app.Run(append([]string{"dbdump"}, tt.args...)); err != nil {
+			t.Fatal(err)
+		}
+		got := config{DBType: target.DBType, Host: target.Host, Port: target.Port, Socket: target.Socket,
+			User: target.User, Password: target.Password, Database: target.Database}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%v: unexpected target %+v", tt.args, got)
+		}
+		if same := target.DBType == gConfig.DBType; target.Given["password"] != same || !target.Given["user"] {
+			t.Errorf("%v: unexpected given %v", tt.args, target.Given)
+		}
+	}
+}
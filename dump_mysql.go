@@ -0,0 +1,384 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/Nutao/dbdump/model"
+)
+
+func init() {
+	RegisterIntrospector("mysql", func() Introspector {
+		return &mysqlIntrospector{}
+	})
+}
+
+// mysqlIntrospector loads schema definitions from MySQL's information_schema.
+type mysqlIntrospector struct {
+	db  *sql.DB
+	cfg *config
+}
+
+func (i *mysqlIntrospector) Open(cfg *config) error {
+	tlsName, err := mysqlTLS(cfg)
+	if err != nil {
+		return err
+	}
+	query := dsnQuery(url.Values{
+		"charset":         {"utf8mb4"},
+		"parseTime":       {"true"},
+		"loc":             {"Local"},
+		"multiStatements": {"true"},
+		"tls":             {tlsName},
+	}, cfg.Params)
+	addr := fmt.Sprintf("tcp(%s:%v)", cfg.Host, cfg.Port)
+	if cfg.Socket != "" {
+		addr = fmt.Sprintf("unix(%s)", cfg.Socket)
+	}
+	dsn := fmt.Sprintf("%s:%s@%s/%s?%s",
+		cfg.User, cfg.Password, addr, "information_schema", query)
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return err
+	}
+	i.db = db
+	i.cfg = cfg
+	return nil
+}
+
+func (i *mysqlIntrospector) Close() error {
+	return i.db.Close()
+}
+
+func (i *mysqlIntrospector) LoadTables() (map[string][]*model.Table, error) {
+	return loadTables(i.db, i.cfg)
+}
+
+func (i *mysqlIntrospector) LoadColumns(map[string][]*model.Table) (map[string]map[string][]*model.Column, error) {
+	return loadColumns(i.db, i.cfg)
+}
+
+func (i *mysqlIntrospector) LoadConstraints() (map[string]map[string]map[string]*model.Constraint, error) {
+	return loadConstraints(i.db, i.cfg)
+}
+
+func (i *mysqlIntrospector) LoadIndexes() (map[string]map[string][]*model.Index, error) {
+	return loadIndexes(i.db, i.cfg)
+}
+
+// loadConstraints loads constraints from database
+//
+// loadTables loads table info from database
+//
+// The format of result is：
+//
+//           /-- database1
+//          /                 /- table1  /- constraint1
+// result --  -- database2 -- -- table2 --  constraint2 -- [column1, column2]
+//          \                 \- table3  \- constraint3
+//           \-- database3
+func loadConstraints(db *sql.DB, cfg *config) (map[string]map[string]map[string]*model.Constraint, error) {
+	builder := squirrel.Select("CONSTRAINT_NAME, TABLE_SCHEMA, TABLE_NAME, COLUMN_NAME, " +
+		"REFERENCED_TABLE_SCHEMA, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME").
+		From("KEY_COLUMN_USAGE")
+	if cfg.Database != "" {
+		builder = builder.Where(squirrel.Eq{"TABLE_SCHEMA": cfg.Database})
+	}
+	if len(cfg.Tables) != 0 {
+		builder = builder.Where(squirrel.Eq{"TABLE_NAME": cfg.Tables})
+	}
+	builder = builder.OrderBy("ORDINAL_POSITION")
+
+	rows, err := builder.RunWith(db).Query()
+	if err != nil {
+		return nil, fmt.Errorf("query constraints info failed, %w", err)
+	}
+	defer rows.Close()
+	result := make(map[string]map[string]map[string]*model.Constraint)
+	for rows.Next() {
+		var column string
+		var refSchema, refTable, refColumn sql.NullString
+		c := &model.Constraint{}
+		if err := rows.Scan(&c.ConstraintName, &c.TableSchema, &c.TableName, &column,
+			&refSchema, &refTable, &refColumn); err != nil {
+			return nil, fmt.Errorf("scan constraints failed, %w", err)
+		}
+
+		constraintsInDB := result[c.TableSchema]
+		if constraintsInDB == nil {
+			constraintsInDB = make(map[string]map[string]*model.Constraint)
+			result[c.TableSchema] = constraintsInDB
+		}
+		constraintsInTable := constraintsInDB[c.TableName]
+		if constraintsInTable == nil {
+			constraintsInTable = make(map[string]*model.Constraint)
+			constraintsInDB[c.TableName] = constraintsInTable
+		}
+		constraint := constraintsInTable[c.ConstraintName]
+		if constraint == nil {
+			constraint = c
+			constraintsInTable[c.ConstraintName] = constraint
+		}
+		constraint.Columns = append(constraint.Columns, column)
+		if refColumn.Valid {
+			constraint.ReferencedTableSchema = refSchema.String
+			constraint.ReferencedTableName = refTable.String
+			constraint.ReferencedColumns = append(constraint.ReferencedColumns, refColumn.String)
+		}
+	}
+
+	// 约束类型存储在TABLE_CONSTRAINTS表中
+	defBuilder := squirrel.Select("TABLE_SCHEMA, TABLE_NAME, CONSTRAINT_NAME, CONSTRAINT_TYPE").
+		From("TABLE_CONSTRAINTS")
+	if cfg.Database != "" {
+		defBuilder = defBuilder.Where(squirrel.Eq{"TABLE_SCHEMA": cfg.Database})
+	}
+	if len(cfg.Tables) != 0 {
+		defBuilder = defBuilder.Where(squirrel.Eq{"TABLE_NAME": cfg.Tables})
+	}
+
+	defRows, err := defBuilder.RunWith(db).Query()
+	if err != nil {
+		return nil, fmt.Errorf("query constraints define failed, %w", err)
+	}
+	defer defRows.Close()
+	for defRows.Next() {
+		var tableSchema, tableName, cName, cType string
+		if err := defRows.Scan(&tableSchema, &tableName, &cName, &cType); err != nil {
+			return nil, fmt.Errorf("scan constraint defines failed, %w", err)
+		}
+		constraintsInDB := result[tableSchema]
+		if constraintsInDB == nil {
+			continue
+		}
+		constraintsInTable := constraintsInDB[tableName]
+		if constraintsInTable == nil {
+			continue
+		}
+		constraint := constraintsInTable[cName]
+		if constraint != nil {
+			constraint.ConstraintType = cType
+		}
+	}
+
+	// 外键的级联规则存储在REFERENTIAL_CONSTRAINTS表中
+	refBuilder := squirrel.Select("CONSTRAINT_SCHEMA, TABLE_NAME, CONSTRAINT_NAME, UPDATE_RULE, DELETE_RULE, MATCH_OPTION").
+		From("REFERENTIAL_CONSTRAINTS")
+	if cfg.Database != "" {
+		refBuilder = refBuilder.Where(squirrel.Eq{"CONSTRAINT_SCHEMA": cfg.Database})
+	}
+	if len(cfg.Tables) != 0 {
+		refBuilder = refBuilder.Where(squirrel.Eq{"TABLE_NAME": cfg.Tables})
+	}
+
+	refRows, err := refBuilder.RunWith(db).Query()
+	if err != nil {
+		return nil, fmt.Errorf("query referential constraints failed, %w", err)
+	}
+	defer refRows.Close()
+	for refRows.Next() {
+		var tableSchema, tableName, cName, updateRule, deleteRule, matchOption string
+		if err := refRows.Scan(&tableSchema, &tableName, &cName, &updateRule, &deleteRule, &matchOption); err != nil {
+			return nil, fmt.Errorf("scan referential constraints failed, %w", err)
+		}
+		if constraint := result[tableSchema][tableName][cName]; constraint != nil {
+			constraint.UpdateRule = updateRule
+			constraint.DeleteRule = deleteRule
+			constraint.MatchOption = matchOption
+		}
+	}
+
+	return result, nil
+}
+
+// loadColumns loads columns from database
+//
+// loadTables loads table info from database
+//
+// The format of result is：
+//
+//           /-- database1
+//          /                 /- table1
+// result --  -- database2 -- -- table2 -- [column1, column2]
+//          \                 \- table3
+//           \-- database3
+func loadColumns(db *sql.DB, cfg *config) (map[string]map[string][]*model.Column, error) {
+	builder := squirrel.Select("TABLE_CATALOG, TABLE_SCHEMA, TABLE_NAME, COLUMN_NAME, ORDINAL_POSITION, " +
+		"COLUMN_DEFAULT, IS_NULLABLE, DATA_TYPE, COLUMN_TYPE, COLUMN_KEY, EXTRA, COLUMN_COMMENT").From("COLUMNS")
+	if cfg.Database != "" {
+		builder = builder.Where(squirrel.Eq{"TABLE_SCHEMA": cfg.Database})
+	}
+	if len(cfg.Tables) != 0 {
+		builder = builder.Where(squirrel.Eq{"TABLE_NAME": cfg.Tables})
+	}
+	builder = builder.OrderBy("TABLE_SCHEMA, TABLE_NAME, ORDINAL_POSITION")
+
+	rows, err := builder.RunWith(db).Query()
+	if err != nil {
+		return nil, fmt.Errorf("query columns info failed, %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[string]map[string][]*model.Column)
+	for rows.Next() {
+		var columnDefault sql.NullString
+		c := &model.Column{}
+		if err := rows.Scan(&c.TableCatalog, &c.TableSchema, &c.TableName, &c.ColumnName, &c.OrdinalPosition,
+			&columnDefault, &c.IsNullable, &c.DataType, &c.ColumnType, &c.ColumnKey, &c.Extra,
+			&c.ColumnComment); err != nil {
+			return nil, fmt.Errorf("scan columns failed, %w", err)
+		}
+		c.ColumnDefaultNull = !columnDefault.Valid
+		c.ColumnDefault = columnDefault.String
+		c.ColumnComment = strings.TrimSpace(c.ColumnComment)
+
+		columnsInDB := result[c.TableSchema]
+		if columnsInDB == nil {
+			columnsInDB = make(map[string][]*model.Column)
+		}
+		columnsInDB[c.TableName] = append(columnsInDB[c.TableName], c)
+
+		result[c.TableSchema] = columnsInDB
+	}
+	return result, nil
+}
+
+// loadTables loads table info from database
+//
+// The format of result is：
+//
+//           /-- database1
+//          /
+// result --  -- database2 -- [table1, table2]
+//          \
+//           \-- database3
+func loadTables(db *sql.DB, cfg *config) (map[string][]*model.Table, error) {
+	builder := squirrel.Select("TABLE_SCHEMA, TABLE_NAME, TABLE_TYPE, TABLE_COMMENT").
+		From("TABLES")
+	if cfg.Database != "" {
+		builder = builder.Where(squirrel.Eq{"TABLE_SCHEMA": cfg.Database})
+	}
+	if len(cfg.Tables) != 0 {
+		builder = builder.Where(squirrel.Eq{"TABLE_NAME": cfg.Tables})
+	}
+	builder = builder.OrderBy("TABLE_SCHEMA, TABLE_NAME")
+
+	rows, err := builder.RunWith(db).Query()
+	if err != nil {
+		return nil, fmt.Errorf("query tables info failed, %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[string][]*model.Table)
+	for rows.Next() {
+		t := &model.Table{}
+		if err := rows.Scan(&t.TableSchema, &t.TableName, &t.TableType, &t.TableComment); err != nil {
+			return nil, fmt.Errorf("scan tables failed, %w", err)
+		}
+		t.TableComment = strings.TrimSpace(t.TableComment)
+		result[t.TableSchema] = append(result[t.TableSchema], t)
+	}
+	return result, nil
+}
+
+// hasColumn reports whether a table of information_schema has the column,
+// columns such as STATISTICS.IS_VISIBLE only exist since MySQL 8.0.
+func hasColumn(db *sql.DB, table, column string) (bool, error) {
+	var count int
+	err := squirrel.Select("COUNT(*)").
+		From("COLUMNS").
+		Where(squirrel.Eq{"TABLE_SCHEMA": "information_schema", "TABLE_NAME": table, "COLUMN_NAME": column}).
+		RunWith(db).QueryRow().Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("query columns of %v failed, %w", table, err)
+	}
+	return count > 0, nil
+}
+
+// loadIndexes loads indexes from database
+//
+// The format of result is：
+//
+//           /-- database1
+//          /                 /- table1
+// result --  -- database2 -- -- table2 -- [index1, index2]
+//          \                 \- table3
+//           \-- database3
+func loadIndexes(db *sql.DB, cfg *config) (map[string]map[string][]*model.Index, error) {
+	visibleColumn, expressionColumn := "'YES'", "NULL"
+	if ok, err := hasColumn(db, "STATISTICS", "IS_VISIBLE"); err != nil {
+		return nil, err
+	} else if ok {
+		visibleColumn = "IS_VISIBLE"
+	}
+	if ok, err := hasColumn(db, "STATISTICS", "EXPRESSION"); err != nil {
+		return nil, err
+	} else if ok {
+		expressionColumn = "EXPRESSION"
+	}
+
+	builder := squirrel.Select("TABLE_SCHEMA, TABLE_NAME, INDEX_NAME, NON_UNIQUE, INDEX_TYPE, INDEX_COMMENT, " +
+		"COLUMN_NAME, COLLATION, SUB_PART, " + visibleColumn + ", " + expressionColumn).
+		From("STATISTICS")
+	if cfg.Database != "" {
+		builder = builder.Where(squirrel.Eq{"TABLE_SCHEMA": cfg.Database})
+	}
+	if len(cfg.Tables) != 0 {
+		builder = builder.Where(squirrel.Eq{"TABLE_NAME": cfg.Tables})
+	}
+	builder = builder.OrderBy("TABLE_SCHEMA, TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX")
+
+	rows, err := builder.RunWith(db).Query()
+	if err != nil {
+		return nil, fmt.Errorf("query indexes info failed, %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[string]map[string][]*model.Index)
+	var last *model.Index
+	for rows.Next() {
+		var nonUnique int
+		var visible string
+		var columnName, collation, expression sql.NullString
+		var subPart sql.NullInt64
+		idx := &model.Index{}
+		if err := rows.Scan(&idx.TableSchema, &idx.TableName, &idx.IndexName, &nonUnique, &idx.IndexType,
+			&idx.Comment, &columnName, &collation, &subPart, &visible, &expression); err != nil {
+			return nil, fmt.Errorf("scan indexes failed, %w", err)
+		}
+
+		// rows of the same index are adjacent, ordered by SEQ_IN_INDEX
+		if last == nil || last.TableSchema != idx.TableSchema || last.TableName != idx.TableName ||
+			last.IndexName != idx.IndexName {
+			idx.Unique = nonUnique == 0
+			idx.Primary = idx.IndexName == "PRIMARY"
+			idx.Visible = visible == "YES"
+			idx.Comment = strings.TrimSpace(idx.Comment)
+
+			indexesInDB := result[idx.TableSchema]
+			if indexesInDB == nil {
+				indexesInDB = make(map[string][]*model.Index)
+				result[idx.TableSchema] = indexesInDB
+			}
+			indexesInDB[idx.TableName] = append(indexesInDB[idx.TableName], idx)
+			last = idx
+		}
+
+		column := &model.IndexColumn{
+			ColumnName: columnName.String,
+			Expression: expression.String,
+			SubPart:    uint32(subPart.Int64),
+		}
+		switch collation.String {
+		case "A":
+			column.Order = "ASC"
+		case "D":
+			column.Order = "DESC"
+		}
+		last.Columns = append(last.Columns, column)
+	}
+	return result, rows.Err()
+}
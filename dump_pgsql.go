@@ -3,13 +3,102 @@ package main
 import (
 	"database/sql"
 	"fmt"
-	"io/ioutil"
+	"net/url"
+	"os"
+	"strconv"
 	"strings"
 
 	"github.com/Masterminds/squirrel"
-	"github.com/urfave/cli/v2"
+	"github.com/Nutao/dbdump/model"
+	"github.com/lib/pq"
 )
 
+func init() {
+	RegisterIntrospector("pgsql", func() Introspector {
+		return &pgsqlIntrospector{}
+	})
+}
+
+// pgsqlIntrospector loads schema definitions from PostgreSQL.
+type pgsqlIntrospector struct {
+	db      *sql.DB
+	cfg     *config
+	schemas *schemaFilter
+}
+
+func (i *pgsqlIntrospector) Open(cfg *config) error {
+	schemas, err := newSchemaFilter(cfg.Schemas)
+	if err != nil {
+		return err
+	}
+	params, err := pgsqlSSLParams(cfg)
+	if err != nil {
+		return err
+	}
+	host := fmt.Sprintf("%s:%v", cfg.Host, cfg.Port)
+	if cfg.Socket != "" {
+		// pq connects to the socket in the directory given by the host parameter
+		dir, port := pgsqlSocket(cfg.Socket, cfg.Port)
+		params.Set("host", dir)
+		params.Set("port", strconv.FormatUint(uint64(port), 10))
+		host = ""
+	}
+	// 密码可能来自凭据文件或输入，需要转义
+	dsn := &url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     host,
+		Path:     "/" + cfg.Database,
+		RawQuery: dsnQuery(params, cfg.Params),
+	}
+	db, err := sql.Open("postgres", dsn.String())
+	if err != nil {
+		return err
+	}
+	i.db = db
+	i.cfg = cfg
+	i.schemas = schemas
+	return nil
+}
+
+func (i *pgsqlIntrospector) Close() error {
+	return i.db.Close()
+}
+
+func (i *pgsqlIntrospector) LoadTables() (map[string][]*model.Table, error) {
+	return loadPGSQLTables(i.db, i.cfg, i.schemas)
+}
+
+// LoadColumns loads columns table by table, pg_attribute is keyed by table oid.
+func (i *pgsqlIntrospector) LoadColumns(tables map[string][]*model.Table) (map[string]map[string][]*model.Column, error) {
+	result := make(map[string]map[string][]*model.Column)
+	for dbName, tablesInDB := range tables {
+		for _, table := range tablesInDB {
+			pgsqlColumnsNew, err := loadPGSQLColumnsNew(i.db, dbName, table.TableName)
+			if err != nil {
+				return nil, err
+			}
+			columnsInDB := result[dbName]
+			if columnsInDB == nil {
+				columnsInDB = make(map[string][]*model.Column)
+				result[dbName] = columnsInDB
+			}
+			if pgsqlColumnsNew[dbName] != nil {
+				columnsInDB[table.TableName] = pgsqlColumnsNew[dbName][table.TableName]
+			}
+		}
+	}
+	return result, nil
+}
+
+func (i *pgsqlIntrospector) LoadConstraints() (map[string]map[string]map[string]*model.Constraint, error) {
+	return loadPGSQLConstraints(i.db, i.cfg, i.schemas)
+}
+
+func (i *pgsqlIntrospector) LoadIndexes() (map[string]map[string][]*model.Index, error) {
+	return loadPGSQLIndexes(i.db, i.cfg, i.schemas)
+}
+
 // loadConstraints loads constraints from database
 //
 // loadTables loads table info from database
@@ -21,14 +110,14 @@ import (
 // result --  -- database2 -- -- table2 --  constraint2 -- [column1, column2]
 //          \                 \- table3  \- constraint3
 //           \-- database3
-func loadPGSQLConstraints(db *sql.DB) (map[string]map[string]map[string]*Constraint, error) {
+func loadPGSQLConstraints(db *sql.DB, cfg *config, schemas *schemaFilter) (map[string]map[string]map[string]*model.Constraint, error) {
 	builder := squirrel.Select("CONSTRAINT_NAME, TABLE_SCHEMA, TABLE_NAME, COLUMN_NAME").
 		From("information_schema.KEY_COLUMN_USAGE")
-	if gConfig.Database != "" {
-		builder = builder.Where(squirrel.Eq{"TABLE_CATALOG": gConfig.Database}).PlaceholderFormat(squirrel.Dollar)
+	if cfg.Database != "" {
+		builder = builder.Where(squirrel.Eq{"TABLE_CATALOG": cfg.Database}).PlaceholderFormat(squirrel.Dollar)
 	}
-	if len(gConfig.Tables) != 0 {
-		builder = builder.Where(squirrel.Eq{"TABLE_NAME": gConfig.Tables}).PlaceholderFormat(squirrel.Dollar)
+	if len(cfg.Tables) != 0 {
+		builder = builder.Where(squirrel.Eq{"TABLE_NAME": cfg.Tables}).PlaceholderFormat(squirrel.Dollar)
 	}
 	builder = builder.OrderBy("ORDINAL_POSITION")
 
@@ -37,21 +126,24 @@ func loadPGSQLConstraints(db *sql.DB) (map[string]map[string]map[string]*Constra
 		return nil, fmt.Errorf("query constraints info failed, %w", err)
 	}
 	defer rows.Close()
-	result := make(map[string]map[string]map[string]*Constraint)
+	result := make(map[string]map[string]map[string]*model.Constraint)
 	for rows.Next() {
 		var column string
-		c := &Constraint{}
+		c := &model.Constraint{}
 		if err := rows.Scan(&c.ConstraintName, &c.TableSchema, &c.TableName, &column); err != nil {
 			return nil, fmt.Errorf("scan constraints failed, %w", err)
 		}
+		if !schemas.Match(c.TableSchema) {
+			continue
+		}
 		constraintsInDB := result[c.TableSchema]
 		if constraintsInDB == nil {
-			constraintsInDB = make(map[string]map[string]*Constraint)
+			constraintsInDB = make(map[string]map[string]*model.Constraint)
 			result[c.TableSchema] = constraintsInDB
 		}
 		constraintsInTable := constraintsInDB[c.TableName]
 		if constraintsInTable == nil {
-			constraintsInTable = make(map[string]*Constraint)
+			constraintsInTable = make(map[string]*model.Constraint)
 			constraintsInDB[c.TableName] = constraintsInTable
 		}
 		constraint := constraintsInTable[c.ConstraintName]
@@ -71,15 +163,15 @@ func loadPGSQLConstraints(db *sql.DB) (map[string]map[string]map[string]*Constra
 	// 约束类型存储在TABLE_CONSTRAINTS表中
 	defBuilder := squirrel.Select("TABLE_SCHEMA, TABLE_NAME, CONSTRAINT_NAME, CONSTRAINT_TYPE").
 		From("information_schema.TABLE_CONSTRAINTS")
-	if gConfig.Database != "" {
-		defBuilder = defBuilder.Where(squirrel.Eq{"TABLE_CATALOG": gConfig.Database}).PlaceholderFormat(squirrel.Dollar)
+	if cfg.Database != "" {
+		defBuilder = defBuilder.Where(squirrel.Eq{"TABLE_CATALOG": cfg.Database}).PlaceholderFormat(squirrel.Dollar)
 	}
 
-	if len(gConfig.Tables) != 0 {
-		defBuilder = defBuilder.Where(squirrel.Eq{"TABLE_NAME": gConfig.Tables}).PlaceholderFormat(squirrel.Dollar)
+	if len(cfg.Tables) != 0 {
+		defBuilder = defBuilder.Where(squirrel.Eq{"TABLE_NAME": cfg.Tables}).PlaceholderFormat(squirrel.Dollar)
 	}
 
-	defBuilder.Where(squirrel.Eq{"TABLE_SCHEMA": schemaList}).PlaceholderFormat(squirrel.Dollar)
+	defBuilder = defBuilder.Where(squirrel.Eq{"TABLE_SCHEMA": schemaList}).PlaceholderFormat(squirrel.Dollar)
 
 	// fmt.Println(defBuilder.ToSql())
 	defRows, err := defBuilder.RunWith(db).Query()
@@ -106,9 +198,74 @@ func loadPGSQLConstraints(db *sql.DB) (map[string]map[string]map[string]*Constra
 		}
 	}
 
+	if err := loadPGSQLForeignKeys(db, cfg, result); err != nil {
+		return nil, err
+	}
+
 	return result, nil
 }
 
+// pgsqlReferentialActions maps pg_constraint.confupdtype/confdeltype to the
+// rules named in information_schema.REFERENTIAL_CONSTRAINTS
+var pgsqlReferentialActions = map[string]string{
+	"a": "NO ACTION",
+	"r": "RESTRICT",
+	"c": "CASCADE",
+	"n": "SET NULL",
+	"d": "SET DEFAULT",
+}
+
+// pgsqlMatchOptions maps pg_constraint.confmatchtype to MATCH_OPTION
+var pgsqlMatchOptions = map[string]string{
+	"s": "NONE",
+	"f": "FULL",
+	"p": "PARTIAL",
+}
+
+// loadPGSQLForeignKeys fills the referenced table, columns and rules of the
+// FOREIGN KEY constraints in constraints from pg_constraint.
+func loadPGSQLForeignKeys(db *sql.DB, cfg *config, constraints map[string]map[string]map[string]*model.Constraint) error {
+	builder := squirrel.Select("n.nspname, t.relname, c.conname, rn.nspname, rt.relname, " +
+		"c.confupdtype, c.confdeltype, c.confmatchtype, " +
+		"ARRAY(SELECT a.attname FROM unnest(c.confkey) WITH ORDINALITY AS k(attnum, ord) " +
+		"JOIN pg_attribute a ON a.attrelid = c.confrelid AND a.attnum = k.attnum ORDER BY k.ord)").
+		From("pg_constraint c").
+		Join("pg_class t ON t.oid = c.conrelid").
+		Join("pg_namespace n ON n.oid = t.relnamespace").
+		Join("pg_class rt ON rt.oid = c.confrelid").
+		Join("pg_namespace rn ON rn.oid = rt.relnamespace").
+		Where(squirrel.Eq{"c.contype": "f"}).
+		PlaceholderFormat(squirrel.Dollar)
+	if len(cfg.Tables) != 0 {
+		builder = builder.Where(squirrel.Eq{"t.relname": cfg.Tables})
+	}
+
+	rows, err := builder.RunWith(db).Query()
+	if err != nil {
+		return fmt.Errorf("query foreign keys failed, %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tableSchema, tableName, cName, refSchema, refTable, updateType, deleteType, matchType string
+		var refColumns []string
+		if err := rows.Scan(&tableSchema, &tableName, &cName, &refSchema, &refTable,
+			&updateType, &deleteType, &matchType, pq.Array(&refColumns)); err != nil {
+			return fmt.Errorf("scan foreign keys failed, %w", err)
+		}
+		constraint := constraints[tableSchema][tableName][cName]
+		if constraint == nil {
+			continue
+		}
+		constraint.ReferencedTableSchema = refSchema
+		constraint.ReferencedTableName = refTable
+		constraint.ReferencedColumns = refColumns
+		constraint.UpdateRule = pgsqlReferentialActions[updateType]
+		constraint.DeleteRule = pgsqlReferentialActions[deleteType]
+		constraint.MatchOption = pgsqlMatchOptions[matchType]
+	}
+	return rows.Err()
+}
+
 // loadColumns loads columns from database
 //
 // loadTables loads table info from database
@@ -167,16 +324,20 @@ func loadPGSQLConstraints(db *sql.DB) (map[string]map[string]map[string]*Constra
 // 	return result, nil
 // }
 
-func loadPGSQLColumnsNew(db *sql.DB, table string) (map[string]map[string][]*Column, error) {
+func loadPGSQLColumnsNew(db *sql.DB, schema, table string) (map[string]map[string][]*model.Column, error) {
 	builder := squirrel.Select("A.attname AS COLUMN_NAME,"+
 		"concat_ws('', t.typname, SUBSTRING(format_type(a.atttypid, a.atttypmod) FROM '\\(.*\\)')), "+
 		"(CASE WHEN (SELECT COUNT (*) FROM pg_constraint WHERE conrelid=A.attrelid AND conkey [ 1 ]=attnum AND contype='p')> 0 THEN 'Y' ELSE 'N' END) AS 主键约束,"+
 		"(CASE WHEN (SELECT COUNT (*) FROM pg_constraint WHERE conrelid=A.attrelid AND conkey [ 1 ]=attnum AND contype='u')> 0 THEN 'Y' ELSE 'N' END) AS 唯一约束,"+
 		"(CASE WHEN (SELECT COUNT (*) FROM pg_constraint WHERE conrelid=A.attrelid AND conkey [ 1 ]=attnum AND contype='f')> 0 THEN 'Y' ELSE 'N' END) AS 外键约束,"+
 		"(CASE WHEN A.attnotnull=TRUE THEN 'N' ELSE 'Y' END) AS NULLABLE,"+
-		"col_description (A.attrelid,A.attnum) AS COMMENT ").
+		"COALESCE(col_description (A.attrelid,A.attnum), '') AS COMMENT, "+
+		"a.attnum, t.typname, "+
+		"(SELECT pg_get_expr(d.adbin, d.adrelid) FROM pg_attrdef d WHERE d.adrelid = a.attrelid AND d.adnum = a.attnum) ").
 		From("pg_attribute a, pg_type t").
-		Where("a.atttypid = t.oid AND attstattarget=-1 and attrelid = (select oid from pg_class where relname = $1)", table).
+		Where("a.atttypid = t.oid AND attstattarget=-1 and attrelid = "+
+			"(select c.oid from pg_class c join pg_namespace n on n.oid = c.relnamespace where c.relname = $1 and n.nspname = $2)",
+			table, schema).
 		OrderBy("a.attnum ASC")
 
 	rows, err := builder.RunWith(db).Query()
@@ -185,10 +346,10 @@ func loadPGSQLColumnsNew(db *sql.DB, table string) (map[string]map[string][]*Col
 	}
 	defer rows.Close()
 
-	result := make(map[string]map[string][]*Column)
+	result := make(map[string]map[string][]*model.Column)
 	for rows.Next() {
-		// var columnDefault sql.NullString
-		c := &Column{}
+		var columnDefault sql.NullString
+		c := &model.Column{}
 		// 接收约束
 		var pk, uk, fk string
 
@@ -201,6 +362,9 @@ func loadPGSQLColumnsNew(db *sql.DB, table string) (map[string]map[string][]*Col
 			&fk,
 			&c.IsNullable,
 			&c.ColumnComment,
+			&c.OrdinalPosition,
+			&c.DataType,
+			&columnDefault,
 		); err != nil {
 			return nil, fmt.Errorf("scan columns failed, %w", err)
 		}
@@ -213,11 +377,13 @@ func loadPGSQLColumnsNew(db *sql.DB, table string) (map[string]map[string][]*Col
 			c.ColumnKey = "FOREIGN KEY"
 		}
 
+		c.ColumnDefaultNull = !columnDefault.Valid
+		c.ColumnDefault = columnDefault.String
 		c.ColumnComment = strings.TrimSpace(c.ColumnComment)
-		c.TableSchema = "public"
+		c.TableSchema = schema
 		columnsInDB := result[c.TableSchema]
 		if columnsInDB == nil {
-			columnsInDB = make(map[string][]*Column)
+			columnsInDB = make(map[string][]*model.Column)
 		}
 		columnsInDB[c.TableName] = append(columnsInDB[c.TableName], c)
 
@@ -235,16 +401,14 @@ func loadPGSQLColumnsNew(db *sql.DB, table string) (map[string]map[string][]*Col
 // result --  -- database2 -- [table1, table2]
 //          \
 //           \-- database3
-func loadPGSQLTables(db *sql.DB) (map[string][]*Table, error) {
+func loadPGSQLTables(db *sql.DB, cfg *config, schemas *schemaFilter) (map[string][]*model.Table, error) {
 	builder := squirrel.Select("TABLE_CATALOG, TABLE_SCHEMA, TABLE_NAME, TABLE_TYPE").
 		From("information_schema.TABLES")
-	if gConfig.Database != "" {
-		builder = builder.Where(squirrel.Eq{"TABLE_CATALOG": gConfig.Database}).PlaceholderFormat(squirrel.Dollar)
+	if cfg.Database != "" {
+		builder = builder.Where(squirrel.Eq{"TABLE_CATALOG": cfg.Database}).PlaceholderFormat(squirrel.Dollar)
 	}
-	builder = builder.Where(squirrel.Eq{"TABLE_SCHEMA": "public"}).PlaceholderFormat(squirrel.Dollar)
-
-	if len(gConfig.Tables) != 0 {
-		builder = builder.Where(squirrel.Eq{"TABLE_NAME": gConfig.Tables}).PlaceholderFormat(squirrel.Dollar)
+	if len(cfg.Tables) != 0 {
+		builder = builder.Where(squirrel.Eq{"TABLE_NAME": cfg.Tables}).PlaceholderFormat(squirrel.Dollar)
 	}
 	// fmt.Println(builder.ToSql())
 	rows, err := builder.RunWith(db).Query()
@@ -253,79 +417,123 @@ func loadPGSQLTables(db *sql.DB) (map[string][]*Table, error) {
 	}
 	defer rows.Close()
 
-	result := make(map[string][]*Table)
+	result := make(map[string][]*model.Table)
 	for rows.Next() {
-		t := &Table{}
+		t := &model.Table{}
 		if err := rows.Scan(&t.TableCatalog, &t.TableSchema, &t.TableName, &t.TableType); err != nil {
 			return nil, fmt.Errorf("scan tables failed, %w", err)
 		}
+		if !schemas.Match(t.TableSchema) {
+			continue
+		}
 		t.TableComment = strings.TrimSpace(t.TableComment)
 		result[t.TableSchema] = append(result[t.TableSchema], t)
 	}
 	return result, nil
 }
 
-// 导出postgres表结构。。。loadTables、loadConstrans函数一样。
-func dumpPGSQL(ctx *cli.Context) error {
-	dsn := fmt.Sprintf("postgres://%s:%s@%s:%v/%s?sslmode=disable",
-		gConfig.User, gConfig.Password, gConfig.Host, gConfig.Port, gConfig.Database)
-	db, err := sql.Open("postgres", dsn)
-	if err != nil {
-		return fmt.Errorf("connect to postgres database failed, %w", err)
+// pgsqlServerVersion returns the version of the server as a number, e.g.
+// 110005 of 11.5 or 90624 of 9.6.24.
+func pgsqlServerVersion(db *sql.DB) (int, error) {
+	var version int
+	if err := db.QueryRow("SELECT current_setting('server_version_num')::int").Scan(&version); err != nil {
+		return 0, fmt.Errorf("query server version failed, %w", err)
 	}
-	defer db.Close()
+	return version, nil
+}
 
-	allTables, err := loadPGSQLTables(db)
+// loadPGSQLIndexes loads indexes from pg_index, one row per key column
+//
+// The format of result is：
+//
+//           /-- schema1
+//          /               /- table1
+// result --  -- schema2 -- -- table2 -- [index1, index2]
+//          \               \- table3
+//           \-- schema3
+func loadPGSQLIndexes(db *sql.DB, cfg *config, schemas *schemaFilter) (map[string]map[string][]*model.Index, error) {
+	version, err := pgsqlServerVersion(db)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	// include columns of covering indexes are not key parts, indnkeyatts only
+	// exists since PostgreSQL 11, which added them
+	keyColumns := "ix.indnkeyatts"
+	if version < 110000 {
+		keyColumns = "ix.indnatts"
 	}
 
-	// allColumns, err := loadPGSQLColumns(db)
-	// if err != nil {
-	// 	return err
-	// }
-	for dbName, tablesInDB := range allTables {
-		for _, table := range tablesInDB {
-			pgsqlColumnsNew, err := loadPGSQLColumnsNew(db, table.TableName)
-			if err != nil {
-				return err
-			}
-			columnsInDB := pgsqlColumnsNew[dbName]
-			if columnsInDB != nil {
-				table.Columns = columnsInDB[table.TableName]
-			}
-		}
+	builder := squirrel.Select("n.nspname, t.relname, i.relname, am.amname, ix.indisunique, ix.indisprimary, " +
+		"ix.indisvalid, COALESCE(pg_get_expr(ix.indpred, ix.indrelid), ''), " +
+		"COALESCE(obj_description(i.oid, 'pg_class'), ''), " +
+		"ix.indkey[k.n - 1] = 0, pg_get_indexdef(ix.indexrelid, k.n, true), " +
+		"(ix.indoption[k.n - 1] & 1) = 1").
+		From("pg_index ix").
+		Join("pg_class i ON i.oid = ix.indexrelid").
+		Join("pg_class t ON t.oid = ix.indrelid").
+		Join("pg_namespace n ON n.oid = t.relnamespace").
+		Join("pg_am am ON am.oid = i.relam").
+		Join("LATERAL generate_series(1, " + keyColumns + ") AS k(n) ON TRUE").
+		PlaceholderFormat(squirrel.Dollar)
+	if len(cfg.Tables) != 0 {
+		builder = builder.Where(squirrel.Eq{"t.relname": cfg.Tables})
 	}
+	builder = builder.OrderBy("n.nspname, t.relname, i.relname, k.n")
 
-	allConstraints, err := loadPGSQLConstraints(db)
+	rows, err := builder.RunWith(db).Query()
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("query indexes info failed, %w", err)
 	}
-	for dbName, tablesInDB := range allTables {
-		for _, table := range tablesInDB {
-			constraintsInDB := allConstraints[dbName]
-			if constraintsInDB == nil {
-				continue
+	defer rows.Close()
+
+	result := make(map[string]map[string][]*model.Index)
+	var last *model.Index
+	for rows.Next() {
+		var isExpression, isDesc, isValid bool
+		var definition string
+		// pgsql has no invisible indexes
+		idx := &model.Index{Visible: true}
+		if err := rows.Scan(&idx.TableSchema, &idx.TableName, &idx.IndexName, &idx.IndexType, &idx.Unique,
+			&idx.Primary, &isValid, &idx.Predicate, &idx.Comment, &isExpression, &definition,
+			&isDesc); err != nil {
+			return nil, fmt.Errorf("scan indexes failed, %w", err)
+		}
+		if !schemas.Match(idx.TableSchema) {
+			continue
+		}
+
+		if last == nil || last.TableSchema != idx.TableSchema || last.TableName != idx.TableName ||
+			last.IndexName != idx.IndexName {
+			// access method names are lower case, e.g. btree, gin
+			idx.IndexType = strings.ToUpper(idx.IndexType)
+			idx.Comment = strings.TrimSpace(idx.Comment)
+			// indisvalid is false while CREATE INDEX CONCURRENTLY runs or after it failed
+			if !isValid {
+				fmt.Fprintf(os.Stderr, "WARNING: index %v.%v is invalid, it is not used by queries\n",
+					idx.TableSchema, idx.IndexName)
 			}
-			for _, constraint := range constraintsInDB[table.TableName] {
-				table.Constraints = append(table.Constraints, constraint)
+
+			indexesInDB := result[idx.TableSchema]
+			if indexesInDB == nil {
+				indexesInDB = make(map[string][]*model.Index)
+				result[idx.TableSchema] = indexesInDB
 			}
+			indexesInDB[idx.TableName] = append(indexesInDB[idx.TableName], idx)
+			last = idx
 		}
-	}
 
-	data, err := gConfig.Formatter.Format(allTables)
-	if err != nil {
-		return fmt.Errorf("formate output failed, %w", err)
-	}
-	if gConfig.Output != "" {
-		if err := ioutil.WriteFile(gConfig.Output, data, 0644); err != nil {
-			return fmt.Errorf("write to output file failed, %w", err)
+		column := &model.IndexColumn{Order: "ASC"}
+		if isDesc {
+			column.Order = "DESC"
+		}
+		if isExpression {
+			column.Expression = definition
+		} else {
+			column.ColumnName = definition
 		}
-	} else {
-		fmt.Println(string(data))
+		last.Columns = append(last.Columns, column)
 	}
-
-	return nil
+	return result, rows.Err()
 }
 
 func ValuesInCondition(values []interface{}) (string, []interface{}) {
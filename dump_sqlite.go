@@ -0,0 +1,433 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/Nutao/dbdump/model"
+)
+
+// sqliteSchema is the name sqlite gives to the main database of a connection.
+const sqliteSchema = "main"
+
+func init() {
+	RegisterIntrospector("sqlite", func() Introspector {
+		return &sqliteIntrospector{}
+	})
+}
+
+// sqliteIntrospector loads schema definitions from a SQLite database file,
+// the file path is given by --database.
+type sqliteIntrospector struct {
+	db  *sql.DB
+	cfg *config
+}
+
+func (i *sqliteIntrospector) Open(cfg *config) error {
+	// Open read only so that a mistyped path is not created as an empty database
+	query := dsnQuery(url.Values{"mode": {"ro"}}, cfg.Params)
+	db, err := sql.Open("sqlite3", sqliteURI(cfg.Database, query))
+	if err != nil {
+		return err
+	}
+	i.db = db
+	i.cfg = cfg
+	return nil
+}
+
+// sqliteURI returns the file: URI of the database file, the path segments are
+// escaped so that ?, # and % in file names are not taken as URI syntax.
+func sqliteURI(file, query string) string {
+	segments := strings.Split(filepath.ToSlash(file), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	u := &url.URL{Scheme: "file", Opaque: strings.Join(segments, "/"), RawQuery: query}
+	return u.String()
+}
+
+func (i *sqliteIntrospector) Close() error {
+	return i.db.Close()
+}
+
+// LoadTables loads tables and views from sqlite_master
+func (i *sqliteIntrospector) LoadTables() (map[string][]*model.Table, error) {
+	builder := squirrel.Select("type, name").
+		From("sqlite_master").
+		Where(squirrel.Eq{"type": []string{"table", "view"}}).
+		Where(squirrel.NotLike{"name": "sqlite_%"})
+	if len(i.cfg.Tables) != 0 {
+		builder = builder.Where(squirrel.Eq{"name": i.cfg.Tables})
+	}
+	builder = builder.OrderBy("name")
+
+	rows, err := builder.RunWith(i.db).Query()
+	if err != nil {
+		return nil, fmt.Errorf("query tables info failed, %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[string][]*model.Table)
+	for rows.Next() {
+		var tableType string
+		t := &model.Table{TableSchema: sqliteSchema}
+		if err := rows.Scan(&tableType, &t.TableName); err != nil {
+			return nil, fmt.Errorf("scan tables failed, %w", err)
+		}
+		t.TableType = "BASE TABLE"
+		if tableType == "view" {
+			t.TableType = "VIEW"
+		}
+		result[t.TableSchema] = append(result[t.TableSchema], t)
+	}
+	return result, rows.Err()
+}
+
+// autoIncrementPattern matches the AUTOINCREMENT keyword of a CREATE TABLE statement
+var autoIncrementPattern = regexp.MustCompile(`(?i)\bAUTOINCREMENT\b`)
+
+// LoadColumns loads columns with PRAGMA table_info, table by table
+func (i *sqliteIntrospector) LoadColumns(tables map[string][]*model.Table) (map[string]map[string][]*model.Column, error) {
+	result := make(map[string]map[string][]*model.Column)
+	for dbName, tablesInDB := range tables {
+		columnsInDB := make(map[string][]*model.Column)
+		result[dbName] = columnsInDB
+		for _, table := range tablesInDB {
+			columns, err := i.loadTableColumns(table.TableName)
+			if err != nil {
+				return nil, err
+			}
+			columnsInDB[table.TableName] = columns
+		}
+	}
+	return result, nil
+}
+
+func (i *sqliteIntrospector) loadTableColumns(table string) ([]*model.Column, error) {
+	var createSQL sql.NullString
+	if err := i.db.QueryRow("SELECT sql FROM sqlite_master WHERE name = ?", table).
+		Scan(&createSQL); err != nil {
+		return nil, fmt.Errorf("query table define failed, %w", err)
+	}
+
+	rows, err := i.db.Query(`SELECT cid, name, type, "notnull", dflt_value, pk FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return nil, fmt.Errorf("query columns info failed, %w", err)
+	}
+	defer rows.Close()
+
+	var result []*model.Column
+	var pkColumns int
+	for rows.Next() {
+		var notNull bool
+		var pk int
+		var columnDefault sql.NullString
+		c := &model.Column{TableSchema: sqliteSchema, TableName: table}
+		if err := rows.Scan(&c.OrdinalPosition, &c.ColumnName, &c.ColumnType, &notNull,
+			&columnDefault, &pk); err != nil {
+			return nil, fmt.Errorf("scan columns failed, %w", err)
+		}
+		// cid starts with 0 while ORDINAL_POSITION of information_schema starts with 1
+		c.OrdinalPosition++
+		c.ColumnDefaultNull = !columnDefault.Valid
+		c.ColumnDefault = columnDefault.String
+		c.IsNullable = "YES"
+		if notNull || pk > 0 {
+			c.IsNullable = "NO"
+		}
+		c.DataType = strings.ToLower(c.ColumnType)
+		if idx := strings.IndexByte(c.DataType, '('); idx >= 0 {
+			c.DataType = strings.TrimSpace(c.DataType[:idx])
+		}
+		if pk > 0 {
+			c.ColumnKey = "PRI"
+			pkColumns++
+		}
+		result = append(result, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan columns failed, %w", err)
+	}
+
+	// AUTOINCREMENT is only allowed on a single INTEGER PRIMARY KEY column
+	if pkColumns == 1 && autoIncrementPattern.MatchString(createSQL.String) {
+		for _, c := range result {
+			if c.ColumnKey == "PRI" {
+				c.Extra = "autoincrement"
+			}
+		}
+	}
+	return result, nil
+}
+
+// LoadConstraints loads primary keys from PRAGMA table_info, unique constraints
+// from PRAGMA index_list and foreign keys from PRAGMA foreign_key_list.
+//
+// SQLite does not keep the name of primary key and foreign key constraints,
+// they are named after MySQL's convention: PRIMARY and <table>_ibfk_<n>.
+func (i *sqliteIntrospector) LoadConstraints() (map[string]map[string]map[string]*model.Constraint, error) {
+	tables, err := i.LoadTables()
+	if err != nil {
+		return nil, err
+	}
+
+	constraintsInDB := make(map[string]map[string]*model.Constraint)
+	for _, table := range tables[sqliteSchema] {
+		if table.TableType == "VIEW" {
+			continue
+		}
+		constraintsInTable := make(map[string]*model.Constraint)
+		constraintsInDB[table.TableName] = constraintsInTable
+
+		if err := i.loadPrimaryKey(table.TableName, constraintsInTable); err != nil {
+			return nil, err
+		}
+		if err := i.loadUniqueConstraints(table.TableName, constraintsInTable); err != nil {
+			return nil, err
+		}
+		if err := i.loadForeignKeys(table.TableName, constraintsInTable); err != nil {
+			return nil, err
+		}
+	}
+
+	return map[string]map[string]map[string]*model.Constraint{sqliteSchema: constraintsInDB}, nil
+}
+
+func (i *sqliteIntrospector) loadPrimaryKey(table string, constraints map[string]*model.Constraint) error {
+	rows, err := i.db.Query("SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk", table)
+	if err != nil {
+		return fmt.Errorf("query primary key failed, %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return fmt.Errorf("scan primary key failed, %w", err)
+		}
+		constraint := constraints["PRIMARY"]
+		if constraint == nil {
+			constraint = &model.Constraint{
+				ConstraintName: "PRIMARY",
+				ConstraintType: "PRIMARY KEY",
+				TableSchema:    sqliteSchema,
+				TableName:      table,
+			}
+			constraints[constraint.ConstraintName] = constraint
+		}
+		constraint.Columns = append(constraint.Columns, column)
+	}
+	return rows.Err()
+}
+
+func (i *sqliteIntrospector) loadUniqueConstraints(table string, constraints map[string]*model.Constraint) error {
+	// origin is 'u' for indexes created by a UNIQUE constraint
+	rows, err := i.db.Query("SELECT name FROM pragma_index_list(?) WHERE origin = 'u' ORDER BY seq", table)
+	if err != nil {
+		return fmt.Errorf("query unique constraints failed, %w", err)
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan unique constraints failed, %w", err)
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("scan unique constraints failed, %w", err)
+	}
+
+	for _, name := range names {
+		columns, err := i.indexColumns(name)
+		if err != nil {
+			return err
+		}
+		constraints[name] = &model.Constraint{
+			ConstraintName: name,
+			ConstraintType: "UNIQUE",
+			TableSchema:    sqliteSchema,
+			TableName:      table,
+			Columns:        columns,
+		}
+	}
+	return nil
+}
+
+func (i *sqliteIntrospector) indexColumns(index string) ([]string, error) {
+	rows, err := i.db.Query("SELECT name FROM pragma_index_info(?) ORDER BY seqno", index)
+	if err != nil {
+		return nil, fmt.Errorf("query index columns failed, %w", err)
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		// name is NULL for expressions
+		var column sql.NullString
+		if err := rows.Scan(&column); err != nil {
+			return nil, fmt.Errorf("scan index columns failed, %w", err)
+		}
+		columns = append(columns, column.String)
+	}
+	return columns, rows.Err()
+}
+
+func (i *sqliteIntrospector) loadForeignKeys(table string, constraints map[string]*model.Constraint) error {
+	rows, err := i.db.Query(`SELECT id, "table", "from", "to", on_update, on_delete, "match"
+		FROM pragma_foreign_key_list(?) ORDER BY id, seq`, table)
+	if err != nil {
+		return fmt.Errorf("query foreign keys failed, %w", err)
+	}
+
+	var implicit []*model.Constraint
+	for rows.Next() {
+		var id int
+		var refTable, column, updateRule, deleteRule, matchOption string
+		var refColumn sql.NullString
+		if err := rows.Scan(&id, &refTable, &column, &refColumn, &updateRule, &deleteRule,
+			&matchOption); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan foreign keys failed, %w", err)
+		}
+		name := fmt.Sprintf("%s_ibfk_%d", table, id+1)
+		constraint := constraints[name]
+		if constraint == nil {
+			constraint = &model.Constraint{
+				ConstraintName:        name,
+				ConstraintType:        "FOREIGN KEY",
+				TableSchema:           sqliteSchema,
+				TableName:             table,
+				ReferencedTableSchema: sqliteSchema,
+				ReferencedTableName:   refTable,
+				UpdateRule:            updateRule,
+				DeleteRule:            deleteRule,
+				MatchOption:           matchOption,
+			}
+			constraints[name] = constraint
+			// "REFERENCES parent" without columns refers to the primary key of parent
+			if !refColumn.Valid {
+				implicit = append(implicit, constraint)
+			}
+		}
+		constraint.Columns = append(constraint.Columns, column)
+		if refColumn.Valid {
+			constraint.ReferencedColumns = append(constraint.ReferencedColumns, refColumn.String)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("scan foreign keys failed, %w", err)
+	}
+
+	for _, constraint := range implicit {
+		primary := make(map[string]*model.Constraint)
+		if err := i.loadPrimaryKey(constraint.ReferencedTableName, primary); err != nil {
+			return err
+		}
+		if pk := primary["PRIMARY"]; pk != nil {
+			constraint.ReferencedColumns = pk.Columns
+		}
+	}
+	return nil
+}
+
+// partialIndexPattern matches the WHERE clause of a CREATE INDEX statement
+var partialIndexPattern = regexp.MustCompile(`(?is)\bWHERE\b(.*)$`)
+
+// LoadIndexes loads indexes with PRAGMA index_list and index_xinfo.
+//
+// The rowid alias of an INTEGER PRIMARY KEY table has no index, so it does not
+// appear here although it is listed as PRIMARY constraint.
+func (i *sqliteIntrospector) LoadIndexes() (map[string]map[string][]*model.Index, error) {
+	tables, err := i.LoadTables()
+	if err != nil {
+		return nil, err
+	}
+
+	indexesInDB := make(map[string][]*model.Index)
+	for _, table := range tables[sqliteSchema] {
+		if table.TableType == "VIEW" {
+			continue
+		}
+		indexes, err := i.loadTableIndexes(table.TableName)
+		if err != nil {
+			return nil, err
+		}
+		indexesInDB[table.TableName] = indexes
+	}
+	return map[string]map[string][]*model.Index{sqliteSchema: indexesInDB}, nil
+}
+
+func (i *sqliteIntrospector) loadTableIndexes(table string) ([]*model.Index, error) {
+	rows, err := i.db.Query(`SELECT l.name, l."unique", l.origin, l.partial, COALESCE(m.sql, '')
+		FROM pragma_index_list(?) AS l LEFT JOIN sqlite_master AS m ON m.type = 'index' AND m.name = l.name
+		ORDER BY l.name`, table)
+	if err != nil {
+		return nil, fmt.Errorf("query indexes info failed, %w", err)
+	}
+	var result []*model.Index
+	for rows.Next() {
+		var origin, createSQL string
+		var partial bool
+		idx := &model.Index{TableSchema: sqliteSchema, TableName: table, IndexType: "BTREE", Visible: true}
+		if err := rows.Scan(&idx.IndexName, &idx.Unique, &origin, &partial, &createSQL); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan indexes failed, %w", err)
+		}
+		idx.Primary = origin == "pk"
+		if partial {
+			if m := partialIndexPattern.FindStringSubmatch(createSQL); m != nil {
+				idx.Predicate = strings.TrimSpace(m[1])
+			}
+		}
+		result = append(result, idx)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan indexes failed, %w", err)
+	}
+
+	for _, idx := range result {
+		if idx.Columns, err = i.indexKeyColumns(idx.IndexName); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (i *sqliteIntrospector) indexKeyColumns(index string) ([]*model.IndexColumn, error) {
+	rows, err := i.db.Query(`SELECT cid, name, "desc" FROM pragma_index_xinfo(?) WHERE key = 1 ORDER BY seqno`,
+		index)
+	if err != nil {
+		return nil, fmt.Errorf("query index columns failed, %w", err)
+	}
+	defer rows.Close()
+
+	var columns []*model.IndexColumn
+	for rows.Next() {
+		var cid int
+		var name sql.NullString
+		var desc bool
+		if err := rows.Scan(&cid, &name, &desc); err != nil {
+			return nil, fmt.Errorf("scan index columns failed, %w", err)
+		}
+		column := &model.IndexColumn{ColumnName: name.String, Order: "ASC"}
+		if desc {
+			column.Order = "DESC"
+		}
+		// cid is -2 for expressions, sqlite does not expose their text
+		if cid == -2 {
+			column.Expression = model.UnknownExpression
+		}
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSQLiteLoadSchema(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dbdump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "test.db")
+	db, err := sql.Open("sqlite3", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE users (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			email VARCHAR(64) NOT NULL UNIQUE,
+			name TEXT DEFAULT 'anonymous'
+		);
+		CREATE TABLE orders (
+			id INTEGER,
+			line INT,
+			user_id INT REFERENCES users ON DELETE CASCADE,
+			PRIMARY KEY (id, line)
+		);
+		CREATE INDEX idx_user_line ON orders (user_id, line DESC) WHERE user_id IS NOT NULL;`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	allTables, err := introspect(&config{DBType: "sqlite", Database: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables := allTables[sqliteSchema]
+	if len(tables) != 2 || tables[0].TableName != "orders" || tables[1].TableName != "users" {
+		t.Fatalf("unexpected tables %+v", tables)
+	}
+
+	users := tables[1]
+	if len(users.Columns) != 3 {
+		t.Fatalf("unexpected columns %+v", users.Columns)
+	}
+	if c := users.Columns[0]; c.ColumnKey != "PRI" || c.Extra != "autoincrement" || c.IsNullable != "NO" {
+		t.Errorf("unexpected id column %+v", c)
+	}
+	if c := users.Columns[1]; c.DataType != "varchar" || c.ColumnType != "VARCHAR(64)" {
+		t.Errorf("unexpected email column %+v", c)
+	}
+	if c := users.Columns[2]; c.ColumnDefaultNull || c.ColumnDefault != "'anonymous'" || c.IsNullable != "YES" {
+		t.Errorf("unexpected name column %+v", c)
+	}
+
+	constraints := make(map[string][]string)
+	for _, c := range append(tables[0].Constraints, users.Constraints...) {
+		constraints[c.TableName+"."+c.ConstraintType] = c.Columns
+	}
+	expected := map[string][]string{
+		"orders.PRIMARY KEY": {"id", "line"},
+		"orders.FOREIGN KEY": {"user_id"},
+		"users.PRIMARY KEY":  {"id"},
+		"users.UNIQUE":       {"email"},
+	}
+	if !reflect.DeepEqual(constraints, expected) {
+		t.Errorf("unexpected constraints %v", constraints)
+	}
+
+	for _, c := range tables[0].Constraints {
+		if c.ConstraintType != "FOREIGN KEY" {
+			continue
+		}
+		if c.ReferencedTableName != "users" || !reflect.DeepEqual(c.ReferencedColumns, []string{"id"}) ||
+			c.DeleteRule != "CASCADE" || c.UpdateRule != "NO ACTION" {
+			t.Errorf("unexpected foreign key %+v", c)
+		}
+	}
+
+	if len(tables[0].Indexes) != 2 {
+		t.Fatalf("unexpected indexes %+v", tables[0].Indexes)
+	}
+	idx := tables[0].Indexes[0]
+	if idx.IndexName != "idx_user_line" || idx.Unique || idx.Predicate != "user_id IS NOT NULL" ||
+		len(idx.Columns) != 2 || idx.Columns[1].ColumnName != "line" || idx.Columns[1].Order != "DESC" {
+		t.Errorf("unexpected index %+v", idx)
+	}
+	if idx := tables[0].Indexes[1]; !idx.Primary || !idx.Unique {
+		t.Errorf("unexpected primary index %+v", idx)
+	}
+}
+
+func TestSQLiteURI(t *testing.T) {
+	if got, want := sqliteURI("/tmp/a?b#c%d.db", "mode=ro"), "file:/tmp/a%3Fb%23c%25d.db?mode=ro"; got != want {
+		t.Errorf("sqliteURI() = %q, want %q", got, want)
+	}
+
+	dir, err := ioutil.TempDir("", "dbdump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "shop?v=1#a%20b.db")
+	db, err := sql.Open("sqlite3", sqliteURI(file, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY)")
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(file); err != nil {
+		t.Fatalf("database not created at %q, %v", file, err)
+	}
+
+	allTables, err := introspect(&config{DBType: "sqlite", Database: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tables := allTables[sqliteSchema]; len(tables) != 1 || tables[0].TableName != "users" {
+		t.Errorf("unexpected tables %+v", tables)
+	}
+}
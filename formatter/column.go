@@ -0,0 +1,82 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+// enumValues returns the values of a mysql enum column, e.g. a and b of
+// enum('a','b'), or nil if c is not an enum.
+func enumValues(c *model.Column) []string {
+	t := c.Type()
+	if !strings.HasPrefix(strings.ToLower(t), "enum(") || !strings.HasSuffix(t, ")") {
+		return nil
+	}
+	var values []string
+	list := t[len("enum(") : len(t)-1]
+	for i := 0; i < len(list); i++ {
+		if list[i] != '\'' {
+			continue
+		}
+		var b strings.Builder
+		for i++; i < len(list); i++ {
+			if list[i] == '\'' && i+1 < len(list) && list[i+1] == '\'' {
+				b.WriteByte('\'')
+				i++
+			} else if list[i] == '\'' {
+				break
+			} else {
+				b.WriteByte(list[i])
+			}
+		}
+		values = append(values, b.String())
+	}
+	return values
+}
+
+// columnValue returns the text of an attribute of column c in the column
+// tables of the data dictionaries, see markdownColumns for the names.
+func columnValue(name string, c *model.Column, labels map[string]string) string {
+	switch name {
+	case "position":
+		return fmt.Sprint(c.OrdinalPosition)
+	case "name":
+		return c.ColumnName
+	case "type":
+		return c.ColumnType
+	case "key":
+		return c.ColumnKey
+	case "nullable":
+		if c.Nullable() {
+			return labels["yes"]
+		}
+		return labels["no"]
+	case "default":
+		if c.ColumnDefaultNull {
+			return "NULL"
+		}
+		return c.ColumnDefault
+	case "comment":
+		return c.ColumnComment
+	case "extra":
+		return c.Extra
+	}
+	return ""
+}
+
+// dictionaryColumns returns the columns option of the column tables, the
+// default is markdownColumns.
+func dictionaryColumns(opts options) ([]string, error) {
+	columns := markdownColumns
+	if v := opts.String("columns", ""); v != "" {
+		columns = strings.Split(v, ",")
+	}
+	for _, c := range columns {
+		if c != "position" && !contains(markdownColumns, c) {
+			return nil, fmt.Errorf("unsupported column %q", c)
+		}
+	}
+	return columns, nil
+}
@@ -0,0 +1,94 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func init() {
+	RegisterFormatter("csv", func() Formatter {
+		return &csvFormatter{}
+	})
+}
+
+// csvFormatter renders a row per column, prefixed by the database, table and
+// table comment, under a header row.
+//
+// Format config:
+//
+//	lang     language of the header, zh or en, default zh
+//	columns  comma separated attributes of the columns as the markdown format,
+//	         default name,type,key,nullable,default,comment,extra
+//	bom      prepend the UTF-8 byte order mark Excel needs to detect the
+//	         encoding, default false
+type csvFormatter struct {
+	labels  map[string]string
+	columns []string
+	bom     bool
+}
+
+func (f *csvFormatter) Initialize(data []byte) error {
+	opts, err := parseOptions(data, "lang", "columns", "bom")
+	if err != nil {
+		return err
+	}
+	lang := opts.String("lang", "zh")
+	if f.labels = labels[lang]; f.labels == nil {
+		return fmt.Errorf("unsupported lang %q", lang)
+	}
+	if f.columns, err = dictionaryColumns(opts); err != nil {
+		return err
+	}
+	f.bom, err = opts.Bool("bom", false)
+	return err
+}
+
+func (f *csvFormatter) Format(val interface{}) ([]byte, error) {
+	tables, ok := val.(map[string][]*model.Table)
+	if !ok {
+		return nil, fmt.Errorf("csv formatter can not format %T", val)
+	}
+	schemas := make([]string, 0, len(tables))
+	for schema := range tables {
+		schemas = append(schemas, schema)
+	}
+	sort.Strings(schemas)
+
+	buffer := &bytes.Buffer{}
+	if f.bom {
+		buffer.WriteString("\ufeff")
+	}
+	w := csv.NewWriter(buffer)
+	header := []string{
+		strings.TrimSpace(f.labels["database"]), f.labels["table"], f.labels["tableComment"],
+	}
+	for _, name := range f.columns {
+		header = append(header, f.labels[name])
+	}
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	for _, schema := range schemas {
+		for _, t := range tables[schema] {
+			for _, c := range t.Columns {
+				row := []string{schema, t.TableName, t.TableComment}
+				for _, name := range f.columns {
+					row = append(row, columnValue(name, c, f.labels))
+				}
+				if err := w.Write(row); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
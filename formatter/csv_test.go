@@ -0,0 +1,36 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func TestCSVFormatter(t *testing.T) {
+	f, err := NewFormatter("csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Initialize([]byte("lang=en&columns=position,name,type,nullable,default,comment")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := f.Format(map[string][]*model.Table{
+		"shop": {
+			{TableName: "users", TableComment: "users, \"all\"", Columns: []*model.Column{
+				{ColumnName: "id", OrdinalPosition: 1, ColumnType: "bigint", IsNullable: "NO", ColumnDefaultNull: true},
+				{ColumnName: "name", OrdinalPosition: 2, ColumnType: "varchar(32)", IsNullable: "YES",
+					ColumnComment: "first\nlast"},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `Database,Table,Table comment,#,Name,Type,Nullable,Default,Comment
+shop,users,"users, ""all""",1,id,bigint,NO,NULL,
+shop,users,"users, ""all""",2,name,varchar(32),YES,,"first
+last"`
+	if string(data) != expected {
+		t.Errorf("unexpected csv\n%s", data)
+	}
+}
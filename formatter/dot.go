@@ -0,0 +1,88 @@
+package formatter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func init() {
+	RegisterFormatter("dot", func() Formatter {
+		return &dotFormatter{}
+	})
+}
+
+// dotFormatter renders tables as Graphviz record nodes, foreign keys are
+// drawn as edges from the referencing column to the referenced column.
+// See graphFormatter for the format config.
+type dotFormatter struct {
+	graphFormatter
+}
+
+func (f *dotFormatter) Initialize(data []byte) error {
+	return f.initialize(data)
+}
+
+func (f *dotFormatter) Format(val interface{}) ([]byte, error) {
+	g, err := f.build("dot", val)
+	if err != nil {
+		return nil, err
+	}
+
+	// ports of the visible columns, keyed by table and column name
+	ports := make(map[*model.Table]map[string]string)
+	var b strings.Builder
+	b.WriteString("digraph schema {\n")
+	b.WriteString("    graph [rankdir=LR];\n")
+	b.WriteString("    node [shape=record, fontname=\"Helvetica\", fontsize=10];\n")
+	b.WriteString("    edge [arrowhead=tee, arrowtail=crow, dir=both];\n")
+	for i, schema := range g.schemas {
+		indent := "    "
+		if f.cluster {
+			fmt.Fprintf(&b, "    subgraph cluster_%d {\n", i)
+			fmt.Fprintf(&b, "        label=%v;\n", dotQuote(schema))
+			indent += "    "
+		}
+		for _, t := range g.tables[schema] {
+			ports[t] = make(map[string]string)
+			fields := []string{dotEscape(t.TableName)}
+			for n, c := range f.visibleColumns(t) {
+				port := "c" + strconv.Itoa(n)
+				ports[t][c.ColumnName] = port
+				field := c.ColumnName + " : " + c.Type()
+				if keys := columnKeys(t, c.ColumnName); len(keys) > 0 {
+					field += " " + strings.Join(keys, ",")
+				}
+				fields = append(fields, "<"+port+"> "+dotEscape(field)+`\l`)
+			}
+			fmt.Fprintf(&b, "%v%v [label=\"{%v}\"];\n", indent, dotQuote(g.ids[t]), strings.Join(fields, "|"))
+		}
+		if f.cluster {
+			b.WriteString("    }\n")
+		}
+	}
+
+	for _, e := range g.edges {
+		from, to := dotQuote(g.ids[e.from]), dotQuote(g.ids[e.to])
+		// the edge is attached to the columns if both of them are drawn
+		if len(e.fk.Columns) > 0 && len(e.fk.ReferencedColumns) > 0 {
+			fromPort, toPort := ports[e.from][e.fk.Columns[0]], ports[e.to][e.fk.ReferencedColumns[0]]
+			if fromPort != "" && toPort != "" {
+				from, to = from+":"+fromPort, to+":"+toPort
+			}
+		}
+		fmt.Fprintf(&b, "    %v -> %v [tooltip=%v];\n", from, to, dotQuote(e.fk.ConstraintName))
+	}
+	b.WriteString("}")
+	return []byte(b.String()), nil
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// dotEscape escapes the characters of record labels.
+var dotEscape = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "{", `\{`, "}", `\}`,
+	"|", `\|`, "<", `\<`, ">", `\>`, "\n", " ").Replace
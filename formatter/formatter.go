@@ -3,6 +3,7 @@ package formatter
 import (
 	"errors"
 	"fmt"
+	"sort"
 )
 
 // Error define
@@ -20,6 +21,38 @@ type Formatter interface {
 	Format(val interface{}) ([]byte, error)
 }
 
+// FileFormatter is implemented by formatters writing a set of files, e.g. a
+// site, instead of a single output.
+type FileFormatter interface {
+	Formatter
+	// FormatFiles returns the content of the files keyed by slash separated path,
+	// or nil if the formatter is configured to write a single output by Format.
+	FormatFiles(val interface{}) (map[string][]byte, error)
+}
+
+// BinaryFormatter is implemented by formatters whose output is not text, e.g.
+// a spreadsheet, which is written to a file only.
+type BinaryFormatter interface {
+	Formatter
+	Binary()
+}
+
+// TemplateFormatter is implemented by formatters rendering user templates,
+// which may use partials defined in other files.
+type TemplateFormatter interface {
+	Formatter
+	// AddTemplate parses the templates of a file, name is the name of the file.
+	AddTemplate(name string, data []byte) error
+}
+
+// SourceFormatter is implemented by formatters whose output depends on the db
+// type the tables are loaded from, e.g. the SQL dialect. SetSource is called
+// before Initialize.
+type SourceFormatter interface {
+	Formatter
+	SetSource(dbType string)
+}
+
 // RegisterFormatter register a formatter
 func RegisterFormatter(name string, create func() Formatter) {
 	formatters[name] = create
@@ -34,11 +67,12 @@ func NewFormatter(name string) (Formatter, error) {
 	return create(), nil
 }
 
-// AllFormatter returns all name of registered formatter
+// AllFormatter returns all name of registered formatter, sorted
 func AllFormatter() []string {
 	names := make([]string, 0, len(formatters))
-	for n, _ := range formatters {
+	for n := range formatters {
 		names = append(names, n)
 	}
+	sort.Strings(names)
 	return names
 }
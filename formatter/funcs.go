@@ -0,0 +1,222 @@
+package formatter
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/Nutao/dbdump/ddl"
+	"github.com/Nutao/dbdump/model"
+)
+
+// TemplateFuncs returns the functions of the gotext templates and of the
+// output path patterns. As the functions of text/template the piped value is
+// passed as the last argument, e.g. {{.TableName | replace "_" " " | title}}.
+//
+// Strings:
+//
+//	lower, upper, title     case conversion
+//	camel, lowerCamel       UserID and userID of user_id
+//	snake, kebab            user_id and user-id of UserID
+//	trim s                  trim spaces
+//	trimPrefix p s, trimSuffix p s, replace old new s
+//	contains sub s, hasPrefix p s, hasSuffix p s
+//	join sep list, split sep s
+//	repeat n s, indent n s  repeat s n times, indent the lines of s by n spaces
+//	padLeft n s, padRight n s
+//	                        pad s to n cells, wide characters take 2 cells
+//	default def v           def if v is empty, e.g. "", 0, nil or empty lists
+//	markdown s              escape s as a markdown table cell: pipes and newlines
+//
+// html, js and urlquery are built in text/template.
+//
+// Columns and tables:
+//
+//	goType c                Go type of the column as the gostruct format, e.g. sql.NullString
+//	sqlType dialect c       type of the column in the dialect, mysql, pgsql or sqlite
+//	column t name           the column of the table, or nil
+//	primaryKey t            columns of the primary key
+//	keys t name             PK, FK and UK markers of the column, e.g. "PK, FK"
+//	foreignKey t name       the foreign key constraint of the column, or nil
+//	fkTarget t name         the column referenced by the column, e.g. users.id, or ""
+//
+// Others:
+//
+//	dict k1 v1 k2 v2 ...    a map, e.g. to pass several values to a partial
+//	list v1 v2 ...          a list
+//	add a b, sub a b        integer arithmetic
+//	include name data       execute the template name and return its output,
+//	                        so that it can be piped, e.g. {{include "row" . | indent 2}}
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"lower":      strings.ToLower,
+		"upper":      strings.ToUpper,
+		"title":      strings.Title,
+		"camel":      camelCase,
+		"lowerCamel": lowerCamelCase,
+		"snake":      snakeCase,
+		"kebab":      func(s string) string { return strings.ReplaceAll(snakeCase(s), "_", "-") },
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"contains":   func(sub, s string) bool { return strings.Contains(s, sub) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"join":       join,
+		"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+		"repeat":     func(n int, s string) string { return strings.Repeat(s, n) },
+		"indent":     indent,
+		"padLeft":    func(n int, s string) string { return strings.Repeat(" ", pad(n, s)) + s },
+		"padRight":   func(n int, s string) string { return s + strings.Repeat(" ", pad(n, s)) },
+		"default":    defaultValue,
+		"markdown":   markdownEscape,
+		"goType":     func(c *model.Column) string { return (&gostructFormatter{}).goType(c) },
+		"sqlType":    ddl.SQLType,
+		"column":     func(t *model.Table, name string) *model.Column { return t.Column(name) },
+		"primaryKey": primaryKeyColumns,
+		"keys":       func(t *model.Table, name string) string { return strings.Join(columnKeys(t, name), ", ") },
+		"foreignKey": foreignKey,
+		"fkTarget":   fkTarget,
+		"dict":       dict,
+		"list":       func(values ...interface{}) []interface{} { return values },
+		"add":        func(a, b int) int { return a + b },
+		"sub":        func(a, b int) int { return a - b },
+	}
+}
+
+// lowerCamelCase returns camelCase(name) with the leading word lower cased,
+// e.g. userID of user_id and id of ID.
+func lowerCamelCase(name string) string {
+	words := nameWords(name)
+	if len(words) == 0 {
+		return ""
+	}
+	return strings.ToLower(words[0]) + camelCase(strings.Join(words[1:], "_"))
+}
+
+// join joins the elements of a list of any type.
+func join(sep string, list interface{}) (string, error) {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", fmt.Errorf("join of %T", list)
+	}
+	elements := make([]string, v.Len())
+	for i := range elements {
+		elements[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return strings.Join(elements, sep), nil
+}
+
+func indent(n int, s string) string {
+	prefix := strings.Repeat(" ", n)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// pad returns the number of spaces padding s to n cells.
+func pad(n int, s string) int {
+	if w := displayWidth(s); w < n {
+		return n - w
+	}
+	return 0
+}
+
+// displayWidth returns the cells s takes in a terminal or monospaced font,
+// east asian wide characters take 2 cells.
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if unicode.In(r, unicode.Han, unicode.Hangul, unicode.Hiragana, unicode.Katakana) ||
+			r >= 0xFF01 && r <= 0xFF60 || r >= 0x3000 && r <= 0x303F {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// defaultValue returns def if v is the zero value or an empty list or map.
+func defaultValue(def, v interface{}) interface{} {
+	if v == nil {
+		return def
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		if rv.Len() == 0 {
+			return def
+		}
+	default:
+		if rv.IsZero() {
+			return def
+		}
+	}
+	return v
+}
+
+// markdownEscape escapes s in a markdown table cell.
+func markdownEscape(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "<br>"), "\n", "<br>")
+}
+
+func primaryKeyColumns(t *model.Table) []string {
+	if pk := t.PrimaryKey(); pk != nil {
+		return pk.Columns
+	}
+	return nil
+}
+
+// foreignKey returns the foreign key of the table including the column.
+func foreignKey(t *model.Table, name string) *model.Constraint {
+	for _, fk := range t.ForeignKeys() {
+		if contains(fk.Columns, name) {
+			return fk
+		}
+	}
+	return nil
+}
+
+// fkTarget returns the column referenced by the column, qualified by the
+// referenced table and, if it differs from the schema of t, by its schema.
+func fkTarget(t *model.Table, name string) string {
+	fk := foreignKey(t, name)
+	if fk == nil {
+		return ""
+	}
+	target := fk.ReferencedTableName
+	if fk.ReferencedTableSchema != "" && fk.ReferencedTableSchema != t.TableSchema {
+		target = fk.ReferencedTableSchema + "." + target
+	}
+	for i, c := range fk.Columns {
+		if c == name && i < len(fk.ReferencedColumns) {
+			return target + "." + fk.ReferencedColumns[i]
+		}
+	}
+	return target
+}
+
+func dict(pairs ...interface{}) (map[string]interface{}, error) {
+	if len(pairs)%2 != 0 {
+		return nil, errors.New("dict expects key value pairs")
+	}
+	result := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict key %v is not a string", pairs[i])
+		}
+		result[key] = pairs[i+1]
+	}
+	return result, nil
+}
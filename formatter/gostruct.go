@@ -0,0 +1,328 @@
+package formatter
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func init() {
+	RegisterFormatter("gostruct", func() Formatter {
+		return &gostructFormatter{}
+	})
+}
+
+// gostructFormatter generates a Go struct per table, nullable columns are
+// mapped to sql.Null* types or pointers.
+//
+// Format config:
+//
+//	package  package name of the generated code, default model
+//	tags     comma separated struct tags of db, json and gorm, default db,json
+//	null     sql or pointer, the type of nullable columns, default sql
+//	split    write a file per table to the output directory, default false
+type gostructFormatter struct {
+	pkg     string
+	tags    []string
+	pointer bool
+	split   bool
+}
+
+func (f *gostructFormatter) Initialize(data []byte) error {
+	opts, err := parseOptions(data, "package", "tags", "null", "split")
+	if err != nil {
+		return err
+	}
+	if f.pkg = opts.String("package", "model"); !token.IsIdentifier(f.pkg) {
+		return fmt.Errorf("invalid package %q", f.pkg)
+	}
+	f.tags = nil
+	for _, tag := range strings.Split(opts.String("tags", "db,json"), ",") {
+		switch tag = strings.TrimSpace(tag); tag {
+		case "db", "json", "gorm":
+			f.tags = append(f.tags, tag)
+		case "none":
+		default:
+			return fmt.Errorf("unsupported tag %q", tag)
+		}
+	}
+	switch null := opts.String("null", "sql"); null {
+	case "sql", "pointer":
+		f.pointer = null == "pointer"
+	default:
+		return fmt.Errorf("unsupported null %q", null)
+	}
+	f.split, err = opts.Bool("split", false)
+	return err
+}
+
+func (f *gostructFormatter) Format(val interface{}) ([]byte, error) {
+	tables, schemas, err := f.tables(val)
+	if err != nil {
+		return nil, err
+	}
+	var structs []*gostruct
+	for _, schema := range schemas {
+		for _, t := range tables[schema] {
+			structs = append(structs, f.build(t, len(schemas) > 1))
+		}
+	}
+	return f.file(structs)
+}
+
+// FormatFiles returns a file per table if split is set, otherwise nil and the
+// package file is returned by Format.
+func (f *gostructFormatter) FormatFiles(val interface{}) (map[string][]byte, error) {
+	if !f.split {
+		return nil, nil
+	}
+	tables, schemas, err := f.tables(val)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte)
+	for _, schema := range schemas {
+		for _, t := range tables[schema] {
+			name := snakeCase(t.TableName)
+			if len(schemas) > 1 {
+				name = snakeCase(schema) + "_" + name
+			}
+			if files[name+".go"] != nil {
+				return nil, fmt.Errorf("duplicate file %v.go of table %v.%v", name, schema, t.TableName)
+			}
+			data, err := f.file([]*gostruct{f.build(t, len(schemas) > 1)})
+			if err != nil {
+				return nil, err
+			}
+			files[name+".go"] = data
+		}
+	}
+	return files, nil
+}
+
+func (f *gostructFormatter) tables(val interface{}) (map[string][]*model.Table, []string, error) {
+	tables, ok := val.(map[string][]*model.Table)
+	if !ok {
+		return nil, nil, fmt.Errorf("gostruct formatter can not format %T", val)
+	}
+	schemas := make([]string, 0, len(tables))
+	for schema := range tables {
+		schemas = append(schemas, schema)
+	}
+	sort.Strings(schemas)
+	return tables, schemas, nil
+}
+
+type gostruct struct {
+	table  *model.Table
+	name   string
+	fields []gostructField
+}
+
+type gostructField struct {
+	name, typ, tag, comment string
+}
+
+// build maps the table to a struct, the struct name is qualified by the
+// schema if qualify is set.
+func (f *gostructFormatter) build(t *model.Table, qualify bool) *gostruct {
+	s := &gostruct{table: t, name: camelCase(t.TableName)}
+	if qualify {
+		s.name = camelCase(t.TableSchema + "_" + t.TableName)
+	}
+	// the TableName method is generated for gorm
+	seen := map[string]bool{"TableName": contains(f.tags, "gorm")}
+	for _, c := range t.Columns {
+		name := camelCase(c.ColumnName)
+		for n := 2; seen[name]; n++ {
+			name = fmt.Sprintf("%v%d", camelCase(c.ColumnName), n)
+		}
+		seen[name] = true
+
+		var tags []string
+		for _, tag := range f.tags {
+			value := c.ColumnName
+			if tag == "gorm" {
+				value = gormTag(t, c)
+			}
+			tags = append(tags, fmt.Sprintf("%v:%q", tag, value))
+		}
+		s.fields = append(s.fields, gostructField{
+			name:    name,
+			typ:     f.goType(c),
+			tag:     strings.Join(tags, " "),
+			comment: strings.Join(strings.Fields(c.ColumnComment), " "),
+		})
+	}
+	return s
+}
+
+// file renders the structs as a gofmt-ed source file.
+func (f *gostructFormatter) file(structs []*gostruct) ([]byte, error) {
+	var body strings.Builder
+	imports := make(map[string]bool)
+	for _, s := range structs {
+		body.WriteString("\n")
+		if comment := strings.Join(strings.Fields(s.table.TableComment), " "); comment != "" {
+			fmt.Fprintf(&body, "// %v %v\n", s.name, comment)
+		} else {
+			fmt.Fprintf(&body, "// %v is a row of table %v.\n", s.name, s.table.TableName)
+		}
+		fmt.Fprintf(&body, "type %v struct {\n", s.name)
+		for _, field := range s.fields {
+			body.WriteString("\t" + field.name + " " + field.typ)
+			if field.tag != "" {
+				body.WriteString(" `" + field.tag + "`")
+			}
+			if field.comment != "" {
+				body.WriteString(" // " + field.comment)
+			}
+			body.WriteString("\n")
+			if strings.Contains(field.typ, "sql.") {
+				imports["database/sql"] = true
+			}
+			if strings.Contains(field.typ, "time.") {
+				imports["time"] = true
+			}
+		}
+		body.WriteString("}\n")
+		if contains(f.tags, "gorm") {
+			fmt.Fprintf(&body, "\n// TableName returns the table name for gorm.\n")
+			fmt.Fprintf(&body, "func (%v) TableName() string {\n\treturn %q\n}\n", s.name, s.table.TableName)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("// Code generated by dbdump. DO NOT EDIT.\n\n")
+	b.WriteString("package " + f.pkg + "\n")
+	var paths []string
+	for _, path := range []string{"database/sql", "time"} {
+		if imports[path] {
+			paths = append(paths, fmt.Sprintf("%q", path))
+		}
+	}
+	switch len(paths) {
+	case 0:
+	case 1:
+		b.WriteString("\nimport " + paths[0] + "\n")
+	default:
+		b.WriteString("\nimport (\n\t" + strings.Join(paths, "\n\t") + "\n)\n")
+	}
+	b.WriteString(body.String())
+
+	data, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return nil, fmt.Errorf("format go source failed, %w", err)
+	}
+	return data, nil
+}
+
+// goType returns the Go type of the column.
+func (f *gostructFormatter) goType(c *model.Column) string {
+	typ := c.GoType()
+	if !c.Nullable() || typ == "[]byte" || typ == "interface{}" {
+		return typ
+	}
+	if f.pointer {
+		return "*" + typ
+	}
+	switch typ {
+	case "bool":
+		return "sql.NullBool"
+	case "int8", "int16", "int32", "uint8", "uint16":
+		return "sql.NullInt32"
+	case "int64", "uint32":
+		return "sql.NullInt64"
+	case "float32", "float64":
+		return "sql.NullFloat64"
+	case "string":
+		return "sql.NullString"
+	case "time.Time":
+		return "sql.NullTime"
+	}
+	// uint64 does not fit in any of the sql.Null* types
+	return "*" + typ
+}
+
+// gormTag returns the gorm tag of the column.
+func gormTag(t *model.Table, c *model.Column) string {
+	parts := []string{"column:" + c.ColumnName}
+	if pk := t.PrimaryKey(); pk != nil && contains(pk.Columns, c.ColumnName) {
+		parts = append(parts, "primaryKey")
+	}
+	if strings.Contains(strings.ToLower(c.Extra), "auto_increment") || strings.HasPrefix(c.ColumnDefault, "nextval(") {
+		parts = append(parts, "autoIncrement")
+	}
+	if typ := c.Type(); typ != "" {
+		parts = append(parts, "type:"+typ)
+	}
+	if !c.Nullable() {
+		parts = append(parts, "not null")
+	}
+	return strings.Join(parts, ";")
+}
+
+// goInitialisms are upper cased in names as golint suggests.
+var goInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true, "GUID": true,
+	"HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true, "LHS": true, "QPS": true,
+	"RAM": true, "RHS": true, "RPC": true, "SLA": true, "SMTP": true, "SQL": true, "SSH": true, "TCP": true,
+	"TLS": true, "TTL": true, "UDP": true, "UI": true, "UID": true, "UUID": true, "URI": true, "URL": true,
+	"UTF8": true, "VM": true, "XML": true, "XMPP": true, "XSRF": true, "XSS": true,
+}
+
+// camelCase returns the exported Go name of a database name, e.g. UserID for
+// user_id.
+func camelCase(name string) string {
+	var b strings.Builder
+	for _, word := range nameWords(name) {
+		if upper := strings.ToUpper(word); goInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		runes := []rune(word)
+		b.WriteString(strings.ToUpper(string(runes[0])) + string(runes[1:]))
+	}
+	result := b.String()
+	if result == "" || !unicode.IsLetter([]rune(result)[0]) {
+		result = "X" + result
+	}
+	return result
+}
+
+// snakeCase returns the lower cased words of name joined by underscores.
+func snakeCase(name string) string {
+	return strings.ToLower(strings.Join(nameWords(name), "_"))
+}
+
+// nameWords splits name into words by the characters other than letters and
+// digits, and by lower to upper case changes.
+func nameWords(name string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+		if unicode.IsUpper(r) && len(word) > 0 && (unicode.IsLower(runes[i-1]) ||
+			i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])) {
+			words = append(words, string(word))
+			word = nil
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
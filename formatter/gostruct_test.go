@@ -0,0 +1,105 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func gostructTables() map[string][]*model.Table {
+	return map[string][]*model.Table{
+		"shop": {
+			{TableSchema: "shop", TableName: "user_accounts", TableComment: "用户", Columns: []*model.Column{
+				{ColumnName: "id", DataType: "bigint", ColumnType: "bigint unsigned", IsNullable: "NO", Extra: "auto_increment"},
+				{ColumnName: "team_id", DataType: "int", ColumnType: "int(11)", IsNullable: "YES"},
+				{ColumnName: "homeURL", DataType: "varchar", ColumnType: "varchar(255)", IsNullable: "YES", ColumnComment: "主页"},
+				{ColumnName: "enabled", DataType: "tinyint", ColumnType: "tinyint(1)", IsNullable: "NO"},
+				{ColumnName: "created_at", DataType: "datetime", ColumnType: "datetime", IsNullable: "NO"},
+				{ColumnName: "avatar", DataType: "blob", ColumnType: "blob", IsNullable: "YES"},
+			}, Constraints: []*model.Constraint{
+				{ConstraintName: "PRIMARY", ConstraintType: model.PrimaryKey, Columns: []string{"id"}},
+			}},
+		},
+	}
+}
+
+func TestGostructFormatter(t *testing.T) {
+	f, err := NewFormatter("gostruct")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Initialize([]byte("package=dao")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := f.Format(gostructTables())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "// Code generated by dbdump. DO NOT EDIT.\n\n" + `package dao
+
+import (
+	"database/sql"
+	"time"
+)
+
+// UserAccounts 用户
+type UserAccounts struct {
+	ID        uint64         ` + "`db:\"id\" json:\"id\"`" + `
+	TeamID    sql.NullInt32  ` + "`db:\"team_id\" json:\"team_id\"`" + `
+	HomeURL   sql.NullString ` + "`db:\"homeURL\" json:\"homeURL\"`" + ` // 主页
+	Enabled   bool           ` + "`db:\"enabled\" json:\"enabled\"`" + `
+	CreatedAt time.Time      ` + "`db:\"created_at\" json:\"created_at\"`" + `
+	Avatar    []byte         ` + "`db:\"avatar\" json:\"avatar\"`" + `
+}
+`
+	if string(data) != expected {
+		t.Errorf("unexpected source\n%s", data)
+	}
+}
+
+func TestGostructFormatterSplit(t *testing.T) {
+	f, err := NewFormatter("gostruct")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Initialize([]byte("split=true&null=pointer&tags=gorm")); err != nil {
+		t.Fatal(err)
+	}
+	files, err := f.(FileFormatter).FormatFiles(gostructTables())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "// Code generated by dbdump. DO NOT EDIT.\n\n" + `package model
+
+import "time"
+
+// UserAccounts 用户
+type UserAccounts struct {
+	ID        uint64    ` + "`gorm:\"column:id;primaryKey;autoIncrement;type:bigint unsigned;not null\"`" + `
+	TeamID    *int32    ` + "`gorm:\"column:team_id;type:int(11)\"`" + `
+	HomeURL   *string   ` + "`gorm:\"column:homeURL;type:varchar(255)\"`" + ` // 主页
+	Enabled   bool      ` + "`gorm:\"column:enabled;type:tinyint(1);not null\"`" + `
+	CreatedAt time.Time ` + "`gorm:\"column:created_at;type:datetime;not null\"`" + `
+	Avatar    []byte    ` + "`gorm:\"column:avatar;type:blob\"`" + `
+}
+
+// TableName returns the table name for gorm.
+func (UserAccounts) TableName() string {
+	return "user_accounts"
+}
+`
+	if len(files) != 1 || string(files["user_accounts.go"]) != expected {
+		t.Errorf("unexpected files %q", files)
+	}
+}
+
+func TestCamelCase(t *testing.T) {
+	for name, expected := range map[string]string{
+		"user_id": "UserID", "homeURL": "HomeURL", "HTTPServer": "HTTPServer", "order items": "OrderItems",
+		"2fa": "X2fa", "名称": "名称",
+	} {
+		if s := camelCase(name); s != expected {
+			t.Errorf("camelCase(%q) = %q, expected %q", name, s, expected)
+		}
+	}
+}
@@ -7,17 +7,21 @@ import (
 
 func init() {
 	RegisterFormatter("gotext", func() Formatter {
-		return &goTextFormatter{
-			Template: template.New(""),
-		}
+		f := &goTextFormatter{}
+		f.Template = template.New("").Funcs(TemplateFuncs()).Funcs(template.FuncMap{
+			"include": f.include,
+		})
+		return f
 	})
 }
 
+// goTextFormatter renders the format config as Go template, with the
+// functions of TemplateFuncs.
 type goTextFormatter struct {
 	*template.Template
 }
 
-func (f goTextFormatter) Initialize(data []byte) error {
+func (f *goTextFormatter) Initialize(data []byte) error {
 	var err error
 	f.Template, err = f.Template.Parse(string(data))
 	if err != nil {
@@ -26,10 +30,27 @@ func (f goTextFormatter) Initialize(data []byte) error {
 	return nil
 }
 
-func (f goTextFormatter) Format(val interface{}) ([]byte, error) {
+// AddTemplate parses a file defining partials, which are available to the
+// template by {{template "name" .}} or {{include "name" .}}.
+func (f *goTextFormatter) AddTemplate(name string, data []byte) error {
+	_, err := f.Template.New(name).Parse(string(data))
+	return err
+}
+
+func (f *goTextFormatter) Format(val interface{}) ([]byte, error) {
 	buffer := &bytes.Buffer{}
 	if err := f.Template.Execute(buffer, val); err != nil {
 		return nil, err
 	}
 	return buffer.Bytes(), nil
 }
+
+// include executes the template name, its output can be piped unlike the
+// template action.
+func (f *goTextFormatter) include(name string, data interface{}) (string, error) {
+	buffer := &bytes.Buffer{}
+	if err := f.Template.ExecuteTemplate(buffer, name, data); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
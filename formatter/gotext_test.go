@@ -0,0 +1,77 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Nutao/dbdump/assets"
+	"github.com/Nutao/dbdump/model"
+)
+
+func TestGoTextFormatter(t *testing.T) {
+	f, err := NewFormatter("gotext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tf := f.(TemplateFormatter)
+	if err := tf.AddTemplate("row.tmpl", []byte(`{{define "row"}}| {{.c.ColumnName | padRight 6}} | {{goType .c}} | {{keys .t .c.ColumnName}} | {{fkTarget .t .c.ColumnName}} | {{.c.ColumnComment | markdown}} |{{end}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Initialize([]byte(`{{range $schema, $tables := .}}{{range $tables}}## {{.TableName | camel}} {{.TableName | replace "_" " " | title}}
+{{$t := .}}{{range .Columns}}{{include "row" (dict "t" $t "c" .) | indent 2}}
+{{end}}{{join ", " (primaryKey .)}} {{default "-" .TableComment}}{{end}}{{end}}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := f.Format(map[string][]*model.Table{
+		"shop": {
+			{TableSchema: "shop", TableName: "order_items", Columns: []*model.Column{
+				{ColumnName: "id", DataType: "bigint", ColumnType: "bigint", IsNullable: "NO"},
+				{ColumnName: "订单", DataType: "int", ColumnType: "int", IsNullable: "YES", ColumnComment: "a|b"},
+			}, Constraints: []*model.Constraint{
+				{ConstraintName: "PRIMARY", ConstraintType: model.PrimaryKey, Columns: []string{"id"}},
+				{ConstraintName: "fk_order", ConstraintType: model.ForeignKey, Columns: []string{"订单"},
+					ReferencedTableSchema: "sales", ReferencedTableName: "orders", ReferencedColumns: []string{"id"}},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `## OrderItems Order Items
+  | id     | int64 | PK |  |  |
+  | 订单   | sql.NullInt32 | FK | sales.orders.id | a\|b |
+id -`
+	if string(data) != expected {
+		t.Errorf("unexpected output\n%s", data)
+	}
+}
+
+// TestGoTextMarkdownAsset checks the constraints and the indexes of the shipped
+// template are separate blockquotes.
+func TestGoTextMarkdownAsset(t *testing.T) {
+	text, err := assets.FS.ReadFile("gotext_md.fc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewFormatter("gotext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Initialize(text); err != nil {
+		t.Fatal(err)
+	}
+	data, err := f.Format(map[string][]*model.Table{"shop": {{
+		TableName:   "users",
+		Columns:     []*model.Column{{ColumnName: "id", ColumnType: "int"}},
+		Constraints: []*model.Constraint{{ConstraintName: "PRIMARY", ConstraintType: model.PrimaryKey, Columns: []string{"id"}}},
+		Indexes:     []*model.Index{{IndexName: "PRIMARY", IndexType: "BTREE", Unique: true, Columns: []*model.IndexColumn{{ColumnName: "id", Order: "ASC"}}}},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "> - **【PRIMARY KEY】PRIMARY**：[id]\n\n> 索引信息\n> - **【BTREE UNIQUE】PRIMARY**：id ASC\n\n| 字段名称"
+	if !strings.Contains(string(data), expected) {
+		t.Errorf("unexpected output\n%s", data)
+	}
+}
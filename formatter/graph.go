@@ -0,0 +1,190 @@
+package formatter
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+// FocusFormatter is implemented by formatters which select the tables around
+// the --tables themselves.
+type FocusFormatter interface {
+	Formatter
+	// Focus sets the tables the output centers on, it reports whether all
+	// tables must be loaded to find their neighbours.
+	Focus(tables []string) bool
+}
+
+// graphFormatter holds the options shared by the diagram formatters.
+//
+// Format config:
+//
+//	cluster  group the tables by schema, default false
+//	columns  all, keys (columns of constraints) or none, default all
+//	hops     also draw the tables within hops foreign keys of --tables, default 0
+type graphFormatter struct {
+	cluster bool
+	columns string
+	hops    int
+	focus   []string
+}
+
+func (g *graphFormatter) initialize(data []byte) error {
+	opts, err := parseOptions(data, "cluster", "columns", "hops")
+	if err != nil {
+		return err
+	}
+	if g.cluster, err = opts.Bool("cluster", false); err != nil {
+		return err
+	}
+	switch g.columns = opts.String("columns", "all"); g.columns {
+	case "all", "keys", "none":
+	default:
+		return fmt.Errorf("unsupported columns %q", g.columns)
+	}
+	if g.hops, err = strconv.Atoi(opts.String("hops", "0")); err != nil || g.hops < 0 {
+		return fmt.Errorf("invalid hops option %q", opts.String("hops", "0"))
+	}
+	return nil
+}
+
+func (g *graphFormatter) Focus(tables []string) bool {
+	g.focus = tables
+	return g.hops > 0 && len(tables) > 0
+}
+
+// graph is the selected tables and the foreign keys between them.
+type graph struct {
+	schemas []string
+	tables  map[string][]*model.Table
+	edges   []graphEdge
+	ids     map[*model.Table]string
+}
+
+type graphEdge struct {
+	from, to *model.Table // from references to
+	fk       *model.Constraint
+}
+
+// build selects the tables of val and resolves the foreign keys.
+func (g *graphFormatter) build(name string, val interface{}) (*graph, error) {
+	tables, ok := val.(map[string][]*model.Table)
+	if !ok {
+		return nil, fmt.Errorf("%v formatter can not format %T", name, val)
+	}
+
+	type key struct{ schema, table string }
+	byKey := make(map[key]*model.Table)
+	for schema, tablesInDB := range tables {
+		for _, t := range tablesInDB {
+			byKey[key{schema, t.TableName}] = t
+		}
+	}
+	var edges []graphEdge
+	for schema, tablesInDB := range tables {
+		for _, t := range tablesInDB {
+			for _, fk := range t.ForeignKeys() {
+				refSchema := fk.ReferencedTableSchema
+				if refSchema == "" {
+					refSchema = schema
+				}
+				if to := byKey[key{refSchema, fk.ReferencedTableName}]; to != nil {
+					edges = append(edges, graphEdge{from: t, to: to, fk: fk})
+				}
+			}
+		}
+	}
+
+	selected := g.neighborhood(tables, edges)
+	result := &graph{tables: make(map[string][]*model.Table), ids: make(map[*model.Table]string)}
+	for schema, tablesInDB := range tables {
+		for _, t := range tablesInDB {
+			if selected == nil || selected[t] {
+				result.tables[schema] = append(result.tables[schema], t)
+			}
+		}
+	}
+	for schema := range result.tables {
+		result.schemas = append(result.schemas, schema)
+	}
+	sort.Strings(result.schemas)
+	for _, schema := range result.schemas {
+		for _, t := range result.tables[schema] {
+			if len(result.schemas) > 1 {
+				result.ids[t] = schema + "." + t.TableName
+			} else {
+				result.ids[t] = t.TableName
+			}
+		}
+	}
+
+	for _, e := range edges {
+		if result.ids[e.from] != "" && result.ids[e.to] != "" {
+			result.edges = append(result.edges, e)
+		}
+	}
+	sort.SliceStable(result.edges, func(i, j int) bool {
+		a, b := result.edges[i], result.edges[j]
+		if result.ids[a.from] != result.ids[b.from] {
+			return result.ids[a.from] < result.ids[b.from]
+		}
+		return a.fk.ConstraintName < b.fk.ConstraintName
+	})
+	return result, nil
+}
+
+// neighborhood returns the tables within hops foreign keys of the focused
+// tables, in either direction. It returns nil if all tables are selected.
+func (g *graphFormatter) neighborhood(tables map[string][]*model.Table, edges []graphEdge) map[*model.Table]bool {
+	if g.hops == 0 || len(g.focus) == 0 {
+		return nil
+	}
+	neighbours := make(map[*model.Table][]*model.Table)
+	for _, e := range edges {
+		neighbours[e.from] = append(neighbours[e.from], e.to)
+		neighbours[e.to] = append(neighbours[e.to], e.from)
+	}
+
+	selected := make(map[*model.Table]bool)
+	var current []*model.Table
+	for _, tablesInDB := range tables {
+		for _, t := range tablesInDB {
+			if contains(g.focus, t.TableName) {
+				selected[t] = true
+				current = append(current, t)
+			}
+		}
+	}
+	for hop := 0; hop < g.hops; hop++ {
+		var next []*model.Table
+		for _, t := range current {
+			for _, n := range neighbours[t] {
+				if !selected[n] {
+					selected[n] = true
+					next = append(next, n)
+				}
+			}
+		}
+		current = next
+	}
+	return selected
+}
+
+// visibleColumns returns the columns drawn for t.
+func (g *graphFormatter) visibleColumns(t *model.Table) []*model.Column {
+	switch g.columns {
+	case "none":
+		return nil
+	case "keys":
+		var result []*model.Column
+		for _, c := range t.Columns {
+			if len(columnKeys(t, c.ColumnName)) > 0 {
+				result = append(result, c)
+			}
+		}
+		return result
+	}
+	return t.Columns
+}
@@ -0,0 +1,97 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func graphTables() map[string][]*model.Table {
+	table := func(name string, refs ...string) *model.Table {
+		t := &model.Table{TableName: name, Columns: []*model.Column{
+			{ColumnName: "id", ColumnType: "bigint", IsNullable: "NO"},
+		}, Constraints: []*model.Constraint{
+			{ConstraintName: name + "_pkey", ConstraintType: model.PrimaryKey, Columns: []string{"id"}},
+		}}
+		for _, ref := range refs {
+			t.Columns = append(t.Columns, &model.Column{ColumnName: ref + "_id", ColumnType: "bigint", IsNullable: "YES"})
+			t.Constraints = append(t.Constraints, &model.Constraint{ConstraintName: "fk_" + ref,
+				ConstraintType: model.ForeignKey, Columns: []string{ref + "_id"},
+				ReferencedTableName: ref, ReferencedColumns: []string{"id"}})
+		}
+		return t
+	}
+	// teams <- users <- orders <- items, logs
+	return map[string][]*model.Table{"shop": {
+		table("teams"), table("users", "teams"), table("orders", "users"), table("items", "orders"), table("logs"),
+	}}
+}
+
+func formatGraph(t *testing.T, name, config string, focus ...string) string {
+	f, err := NewFormatter(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Initialize([]byte(config)); err != nil {
+		t.Fatal(err)
+	}
+	if len(focus) > 0 && !f.(FocusFormatter).Focus(focus) {
+		t.Fatal("expected all tables to be loaded")
+	}
+	data, err := f.Format(graphTables())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestDotFormatter(t *testing.T) {
+	data := formatGraph(t, "dot", "cluster=true&hops=1", "users")
+	for _, s := range []string{
+		`subgraph cluster_0 {`,
+		`        "users" [label="{users|<c0> id : bigint PK\l|<c1> teams_id : bigint FK\l}"];`,
+		`    "users":c1 -> "teams":c0 [tooltip="fk_teams"];`,
+		`    "orders":c1 -> "users":c0 [tooltip="fk_users"];`,
+	} {
+		if !strings.Contains(data, s) {
+			t.Errorf("expected %q in\n%s", s, data)
+		}
+	}
+	if strings.Contains(data, "items") || strings.Contains(data, "logs") {
+		t.Errorf("unexpected tables in\n%s", data)
+	}
+
+	data = formatGraph(t, "dot", "columns=none")
+	if !strings.Contains(data, `    "users" [label="{users}"];`) || !strings.Contains(data, `    "users" -> "teams" [tooltip="fk_teams"];`) {
+		t.Errorf("unexpected graph\n%s", data)
+	}
+}
+
+func TestPlantUMLFormatter(t *testing.T) {
+	data := formatGraph(t, "plantuml", "columns=keys&hops=2", "items")
+	expected := `@startuml
+hide circle
+skinparam linetype ortho
+entity "users" as users {
+    * id : bigint <<PK>>
+    --
+    teams_id : bigint <<FK>>
+}
+entity "orders" as orders {
+    * id : bigint <<PK>>
+    --
+    users_id : bigint <<FK>>
+}
+entity "items" as items {
+    * id : bigint <<PK>>
+    --
+    orders_id : bigint <<FK>>
+}
+orders |o..o{ items : fk_orders
+users |o..o{ orders : fk_users
+@enduml`
+	if data != expected {
+		t.Errorf("unexpected diagram\n%s", data)
+	}
+}
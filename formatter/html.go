@@ -0,0 +1,217 @@
+package formatter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/Nutao/dbdump/assets"
+	"github.com/Nutao/dbdump/model"
+)
+
+func init() {
+	RegisterFormatter("html", func() Formatter {
+		return &htmlFormatter{}
+	})
+}
+
+// htmlFormatter renders tables as a static site: an index page, a page per
+// database and a page per table, with the stylesheet and script embedded in
+// the binary.
+//
+// Format config:
+//
+//	lang   language of the texts, zh or en, default zh
+//	title  title of the site, default 数据字典 or Data Dictionary
+type htmlFormatter struct {
+	*template.Template
+	site htmlSite
+}
+
+type htmlSite struct {
+	Lang    string
+	Title   string
+	L       map[string]string
+	Schemas []*htmlSchema
+}
+
+type htmlSchema struct {
+	Name   string
+	Path   string
+	Tables []*htmlTable
+}
+
+type htmlTable struct {
+	*model.Table
+	Path         string
+	Search       string                // lower case text searched by the search box
+	Links        map[string]*htmlTable // referenced tables by foreign key name
+	ReferencedBy []*htmlReference
+}
+
+// htmlReference is a foreign key of Table referencing another table.
+type htmlReference struct {
+	*model.Constraint
+	Table *htmlTable
+}
+
+// htmlPage is the data of a page template.
+type htmlPage struct {
+	Site   *htmlSite
+	Title  string
+	Root   string // relative path to the root of the site
+	Schema *htmlSchema
+	Table  *htmlTable
+}
+
+// WithSchema returns the page with the schema, for the tables template.
+func (p htmlPage) WithSchema(schema *htmlSchema) htmlPage {
+	p.Schema = schema
+	return p
+}
+
+func (f *htmlFormatter) Initialize(data []byte) error {
+	opts, err := parseOptions(data, "lang", "title")
+	if err != nil {
+		return err
+	}
+	f.site.Lang = opts.String("lang", "zh")
+	if f.site.L = labels[f.site.Lang]; f.site.L == nil {
+		return fmt.Errorf("unsupported lang %q", f.site.Lang)
+	}
+	f.site.Title = opts.String("title", f.site.L["title"])
+
+	text, err := assets.FS.ReadFile("html/site.tmpl")
+	if err != nil {
+		return err
+	}
+	f.Template, err = template.New("html").Funcs(template.FuncMap{
+		"join": func(values []string) string { return strings.Join(values, ", ") },
+	}).Parse(string(text))
+	return err
+}
+
+// Format fails, the site is written by FormatFiles.
+func (f *htmlFormatter) Format(val interface{}) ([]byte, error) {
+	return nil, errors.New("html formatter writes a site, the output directory is required")
+}
+
+func (f *htmlFormatter) FormatFiles(val interface{}) (map[string][]byte, error) {
+	tables, ok := val.(map[string][]*model.Table)
+	if !ok {
+		return nil, fmt.Errorf("html formatter can not format %T", val)
+	}
+	site := f.buildSite(tables)
+
+	files := make(map[string][]byte)
+	for _, name := range []string{"style.css", "site.js"} {
+		data, err := assets.FS.ReadFile("html/" + name)
+		if err != nil {
+			return nil, err
+		}
+		files["static/"+name] = data
+	}
+
+	render := func(path, name string, page htmlPage) error {
+		page.Site = site
+		buffer := &bytes.Buffer{}
+		if err := f.ExecuteTemplate(buffer, name, page); err != nil {
+			return fmt.Errorf("render %v failed, %w", path, err)
+		}
+		files[path] = buffer.Bytes()
+		return nil
+	}
+	if err := render("index.html", "index", htmlPage{Title: site.Title}); err != nil {
+		return nil, err
+	}
+	for _, schema := range site.Schemas {
+		root := "../"
+		if err := render(schemaDir(schema.Name)+"/index.html", "schema",
+			htmlPage{Title: schema.Name + " - " + site.Title, Root: root, Schema: schema}); err != nil {
+			return nil, err
+		}
+		for _, t := range schema.Tables {
+			if err := render(schemaDir(schema.Name)+"/"+fileName(t.TableName)+".html", "table",
+				htmlPage{Title: t.TableName + " - " + site.Title, Root: root, Schema: schema, Table: t}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return files, nil
+}
+
+// buildSite orders the schemas and resolves the foreign key links.
+func (f *htmlFormatter) buildSite(tables map[string][]*model.Table) *htmlSite {
+	site := f.site
+	site.Schemas = nil
+
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	type key struct{ schema, table string }
+	byKey := make(map[key]*htmlTable)
+	for _, name := range names {
+		dir := url.PathEscape(schemaDir(name))
+		schema := &htmlSchema{Name: name, Path: dir + "/index.html"}
+		for _, t := range tables[name] {
+			ht := &htmlTable{Table: t, Path: dir + "/" + url.PathEscape(fileName(t.TableName)) + ".html",
+				Search: searchText(t), Links: make(map[string]*htmlTable)}
+			schema.Tables = append(schema.Tables, ht)
+			byKey[key{name, t.TableName}] = ht
+		}
+		site.Schemas = append(site.Schemas, schema)
+	}
+
+	for _, schema := range site.Schemas {
+		for _, t := range schema.Tables {
+			for _, fk := range t.ForeignKeys() {
+				refSchema := fk.ReferencedTableSchema
+				if refSchema == "" {
+					refSchema = schema.Name
+				}
+				target := byKey[key{refSchema, fk.ReferencedTableName}]
+				if target == nil {
+					continue
+				}
+				t.Links[fk.ConstraintName] = target
+				target.ReferencedBy = append(target.ReferencedBy, &htmlReference{Constraint: fk, Table: t})
+			}
+		}
+	}
+	return &site
+}
+
+// searchText returns the names and comments of the table and its columns.
+func searchText(t *model.Table) string {
+	parts := []string{t.TableName, t.TableComment}
+	for _, c := range t.Columns {
+		parts = append(parts, c.ColumnName, c.ColumnComment)
+	}
+	return strings.ToLower(strings.Join(parts, " "))
+}
+
+// schemaDir returns the directory of the schema pages, an empty schema name
+// is possible for parsed DDL files.
+func schemaDir(schema string) string {
+	if schema == "" {
+		return "_"
+	}
+	return fileName(schema)
+}
+
+// fileName replaces the characters not allowed in file names.
+func fileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+}
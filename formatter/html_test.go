@@ -0,0 +1,54 @@
+package formatter
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func TestHTMLFormatter(t *testing.T) {
+	f, err := NewFormatter("html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Initialize([]byte("lang=en&title=Shop")); err != nil {
+		t.Fatal(err)
+	}
+	files, err := f.(FileFormatter).FormatFiles(map[string][]*model.Table{
+		"shop": {
+			{TableName: "teams", TableComment: "<Teams>"},
+			{TableName: "users/v2", Constraints: []*model.Constraint{
+				{ConstraintName: "fk_team", ConstraintType: model.ForeignKey, Columns: []string{"team_id"},
+					ReferencedTableName: "teams", ReferencedColumns: []string{"id"}},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	expected := []string{"index.html", "shop/index.html", "shop/teams.html", "shop/users_v2.html",
+		"static/site.js", "static/style.css"}
+	if strings.Join(paths, ",") != strings.Join(expected, ",") {
+		t.Fatalf("unexpected files %q", paths)
+	}
+
+	for path, contents := range map[string][]string{
+		"index.html":         {"<title>Shop</title>", `<a href="shop/users_v2.html">users/v2</a>`, "&lt;Teams&gt;"},
+		"shop/users_v2.html": {`→ <a href="../shop/teams.html">teams</a> (id)`},
+		"shop/teams.html":    {`<a href="../shop/users_v2.html">users/v2</a> (team_id) <code>fk_team</code>`},
+	} {
+		for _, content := range contents {
+			if !strings.Contains(string(files[path]), content) {
+				t.Errorf("%q not found in %v:\n%s", content, path, files[path])
+			}
+		}
+	}
+}
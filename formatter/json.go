@@ -1,6 +1,15 @@
 package formatter
 
-import "encoding/json"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Nutao/dbdump/model"
+)
 
 func init() {
 	RegisterFormatter("json", func() Formatter {
@@ -8,13 +17,101 @@ func init() {
 	})
 }
 
+// jsonFormatter renders the value as json, a single compact line by default.
+//
+// Format config:
+//
+//	indent     number of spaces, or tab, to indent each level with, default
+//	           no indentation
+//	ndjson     render a table per line, ordered by schema, default false
+//
+// and the fields and omitempty options of treeOptions.
 type jsonFormatter struct {
+	treeOptions
+	indent string
+	ndjson bool
 }
 
-func (j jsonFormatter) Initialize(data []byte) error {
-	return nil
+func (j *jsonFormatter) Initialize(data []byte) error {
+	opts, err := parseOptions(data, append(treeKeys, "indent", "ndjson")...)
+	if err != nil {
+		return err
+	}
+	if err := j.treeOptions.initialize(opts); err != nil {
+		return err
+	}
+	switch indent := opts.String("indent", ""); indent {
+	case "", "0":
+		j.indent = ""
+	case "tab":
+		j.indent = "\t"
+	default:
+		n, err := strconv.Atoi(indent)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid indent option %q", indent)
+		}
+		j.indent = strings.Repeat(" ", n)
+	}
+	j.ndjson, err = opts.Bool("ndjson", false)
+	return err
 }
 
-func (j jsonFormatter) Format(val interface{}) ([]byte, error) {
-	return json.Marshal(val)
+func (j *jsonFormatter) Format(val interface{}) ([]byte, error) {
+	if j.ndjson {
+		return j.formatLines(val)
+	}
+	if j.indent == "" && !j.selected() {
+		return json.Marshal(val)
+	}
+
+	tree, err := j.tree(val)
+	if err != nil {
+		return nil, err
+	}
+	// the tree is encoded by json.Marshal as well, so that html characters
+	// are escaped the same way with or without indentation
+	data, err := json.Marshal(tree)
+	if err != nil || j.indent == "" {
+		return data, err
+	}
+	var b bytes.Buffer
+	if err := json.Indent(&b, data, "", j.indent); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// formatLines renders each table in a line.
+func (j *jsonFormatter) formatLines(val interface{}) ([]byte, error) {
+	tables, ok := val.(map[string][]*model.Table)
+	if !ok {
+		return nil, fmt.Errorf("json formatter can not format %T as ndjson", val)
+	}
+	schemas := make([]string, 0, len(tables))
+	for schema := range tables {
+		schemas = append(schemas, schema)
+	}
+	sort.Strings(schemas)
+
+	var lines []string
+	for _, schema := range schemas {
+		for _, t := range tables[schema] {
+			// the schema is kept for the snapshot to be loaded
+			if t.TableSchema == "" {
+				copied := *t
+				copied.TableSchema = schema
+				t = &copied
+			}
+			tree, err := j.tree(t)
+			if err != nil {
+				return nil, err
+			}
+			data, err := json.Marshal(tree)
+			if err != nil {
+				return nil, err
+			}
+			lines = append(lines, string(data))
+		}
+	}
+	return []byte(strings.Join(lines, "\n")), nil
 }
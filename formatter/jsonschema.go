@@ -0,0 +1,150 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func init() {
+	RegisterFormatter("jsonschema", func() Formatter {
+		return &jsonSchemaFormatter{}
+	})
+}
+
+// jsonSchemaFormatter generates a draft 2020-12 JSON Schema with the schema of
+// each table in $defs, keyed by table name.
+//
+// Format config:
+//
+//	id      $id of the schema, not written by default
+//	strict  disallow properties other than the columns, default false
+type jsonSchemaFormatter struct {
+	id     string
+	strict bool
+}
+
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	ID                   string                 `json:"$id,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 interface{}            `json:"type,omitempty"` // a type or list of types
+	Format               string                 `json:"format,omitempty"`
+	ContentEncoding      string                 `json:"contentEncoding,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	MaxLength            int                    `json:"maxLength,omitempty"`
+	Minimum              *int                   `json:"minimum,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Defs                 map[string]*jsonSchema `json:"$defs,omitempty"`
+}
+
+func (f *jsonSchemaFormatter) Initialize(data []byte) error {
+	opts, err := parseOptions(data, "id", "strict")
+	if err != nil {
+		return err
+	}
+	f.id = opts.String("id", "")
+	f.strict, err = opts.Bool("strict", false)
+	return err
+}
+
+func (f *jsonSchemaFormatter) Format(val interface{}) ([]byte, error) {
+	tables, ok := val.(map[string][]*model.Table)
+	if !ok {
+		return nil, fmt.Errorf("jsonschema formatter can not format %T", val)
+	}
+
+	root := &jsonSchema{
+		Schema: "https://json-schema.org/draft/2020-12/schema",
+		ID:     f.id,
+		Defs:   make(map[string]*jsonSchema),
+	}
+	for schema, tablesInDB := range tables {
+		for _, t := range tablesInDB {
+			name := t.TableName
+			if len(tables) > 1 {
+				name = schema + "." + t.TableName
+			}
+			root.Defs[name] = f.table(t)
+		}
+	}
+	return json.MarshalIndent(root, "", "  ")
+}
+
+func (f *jsonSchemaFormatter) table(t *model.Table) *jsonSchema {
+	s := &jsonSchema{
+		Title:       t.TableName,
+		Description: t.TableComment,
+		Type:        "object",
+		Properties:  make(map[string]*jsonSchema),
+	}
+	if f.strict {
+		s.AdditionalProperties = new(bool)
+	}
+	for _, c := range t.Columns {
+		s.Properties[c.ColumnName] = jsonSchemaColumn(c)
+		// nullable columns are still required, their value may be null
+		s.Required = append(s.Required, c.ColumnName)
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+// jsonSchemaColumn returns the schema of the column value.
+func jsonSchemaColumn(c *model.Column) *jsonSchema {
+	s := &jsonSchema{Description: c.ColumnComment, MaxLength: c.MaxLength()}
+	if values := enumValues(c); len(values) > 0 {
+		for _, v := range values {
+			s.Enum = append(s.Enum, v)
+		}
+		if c.Nullable() {
+			s.Enum = append(s.Enum, nil)
+		}
+		return s
+	}
+
+	var typ string
+	switch goType := c.GoType(); goType {
+	case "bool":
+		typ = "boolean"
+	case "int8", "int16", "int32", "int64", "uint8", "uint16", "uint32", "uint64":
+		typ = "integer"
+		if strings.HasPrefix(goType, "u") {
+			s.Minimum = new(int)
+		}
+	case "float32", "float64":
+		typ = "number"
+	case "time.Time":
+		typ, s.Format = "string", "date-time"
+		if strings.ToLower(c.DataType) == "date" {
+			s.Format = "date"
+		}
+	case "[]byte":
+		typ, s.ContentEncoding = "string", "base64"
+	case "interface{}":
+		// any value
+		return s
+	default:
+		typ = "string"
+		switch strings.ToLower(c.DataType) {
+		case "json", "jsonb":
+			return s
+		case "uuid":
+			s.Format = "uuid"
+		case "time":
+			s.Format = "time"
+		}
+	}
+	if c.Nullable() {
+		s.Type = []string{typ, "null"}
+	} else {
+		s.Type = typ
+	}
+	return s
+}
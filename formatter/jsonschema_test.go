@@ -0,0 +1,63 @@
+package formatter
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func TestJSONSchemaFormatter(t *testing.T) {
+	f, err := NewFormatter("jsonschema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Initialize([]byte("strict=true")); err != nil {
+		t.Fatal(err)
+	}
+	tables := map[string][]*model.Table{"shop": {
+		{TableSchema: "shop", TableName: "user_accounts", TableComment: "用户", Columns: []*model.Column{
+			{ColumnName: "id", DataType: "bigint", ColumnType: "bigint unsigned", IsNullable: "NO", Extra: "auto_increment"},
+			{ColumnName: "team_id", DataType: "int", ColumnType: "int(11)", IsNullable: "YES"},
+			{ColumnName: "homeURL", DataType: "varchar", ColumnType: "varchar(255)", IsNullable: "YES", ColumnComment: "主页"},
+			{ColumnName: "enabled", DataType: "tinyint", ColumnType: "tinyint(1)", IsNullable: "NO"},
+			{ColumnName: "created_at", DataType: "datetime", ColumnType: "datetime", IsNullable: "NO"},
+			{ColumnName: "avatar", DataType: "blob", ColumnType: "blob", IsNullable: "YES"},
+			{ColumnName: "status", DataType: "enum", ColumnType: "enum('active','it''s')", IsNullable: "YES"},
+		}},
+	}}
+	data, err := f.Format(tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$defs": map[string]interface{}{
+			"user_accounts": map[string]interface{}{
+				"title":       "user_accounts",
+				"description": "用户",
+				"type":        "object",
+				"properties": map[string]interface{}{
+					"id":         map[string]interface{}{"type": "integer", "minimum": 0.0},
+					"team_id":    map[string]interface{}{"type": []interface{}{"integer", "null"}},
+					"homeURL":    map[string]interface{}{"type": []interface{}{"string", "null"}, "maxLength": 255.0, "description": "主页"},
+					"enabled":    map[string]interface{}{"type": "boolean"},
+					"created_at": map[string]interface{}{"type": "string", "format": "date-time"},
+					"avatar":     map[string]interface{}{"type": []interface{}{"string", "null"}, "contentEncoding": "base64"},
+					"status":     map[string]interface{}{"enum": []interface{}{"active", "it's", nil}},
+				},
+				"required":             []interface{}{"avatar", "created_at", "enabled", "homeURL", "id", "status", "team_id"},
+				"additionalProperties": false,
+			},
+		},
+	}
+	if !reflect.DeepEqual(schema, expected) {
+		t.Errorf("unexpected schema\n%s", data)
+	}
+}
@@ -0,0 +1,73 @@
+package formatter
+
+// labels holds the texts of the documents in the supported languages
+var labels = map[string]map[string]string{
+	"zh": {
+		"toc":          "目录",
+		"database":     "数据库",
+		"back":         "回到顶部",
+		"constraints":  "约束信息",
+		"indexes":      "索引信息",
+		"view":         "视图",
+		"(":            "（",
+		")":            "）",
+		"yes":          "是",
+		"no":           "否",
+		"position":     "序号",
+		"name":         "字段名称",
+		"type":         "字段类型",
+		"key":          "KEY",
+		"nullable":     "是否可空",
+		"default":      "默认值",
+		"comment":      "备注",
+		"extra":        "Extra",
+		"title":        "数据字典",
+		"search":       "搜索表名、字段、注释",
+		"table":        "表名",
+		"tableComment": "表注释",
+		"tables":       "表数",
+		"columns":      "字段",
+		"columnCount":  "字段数",
+		"indexName":    "索引名称",
+		"indexType":    "索引类型",
+		"unique":       "唯一",
+		"predicate":    "条件",
+		"references":   "引用",
+		"referencedBy": "被引用",
+		"overview":     "概览",
+	},
+	"en": {
+		"toc":          "Contents",
+		"database":     "Database ",
+		"back":         "Back to top",
+		"constraints":  "Constraints",
+		"indexes":      "Indexes",
+		"view":         "view",
+		"(":            " (",
+		")":            ")",
+		"yes":          "YES",
+		"no":           "NO",
+		"position":     "#",
+		"name":         "Name",
+		"type":         "Type",
+		"key":          "Key",
+		"nullable":     "Nullable",
+		"default":      "Default",
+		"comment":      "Comment",
+		"extra":        "Extra",
+		"title":        "Data Dictionary",
+		"search":       "Search tables, columns and comments",
+		"table":        "Table",
+		"tableComment": "Table comment",
+		"tables":       "Tables",
+		"columns":      "Columns",
+		"columnCount":  "Columns",
+		"indexName":    "Index",
+		"indexType":    "Type",
+		"unique":       "Unique",
+		"predicate":    "Predicate",
+		"references":   "References",
+		"referencedBy": "Referenced by",
+		"overview":     "Overview",
+	},
+}
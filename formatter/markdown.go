@@ -0,0 +1,192 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/Nutao/dbdump/assets"
+	"github.com/Nutao/dbdump/model"
+)
+
+func init() {
+	RegisterFormatter("markdown", func() Formatter {
+		return &markdownFormatter{}
+	})
+}
+
+// markdownColumns are the columns of the column table, in the default order
+var markdownColumns = []string{"name", "type", "key", "nullable", "default", "comment", "extra"}
+
+// markdownFormatter renders tables as Markdown with the embedded template.
+//
+// Format config:
+//
+//	lang     language of the texts, zh or en, default zh
+//	toc      render a table of contents, default true
+//	anchor   github, html or none, default github. github links to the
+//	         heading ids generated by GitHub and GitLab, html declares
+//	         <a id> anchors, none renders no links
+//	columns  comma separated columns of the column table, default
+//	         name,type,key,nullable,default,comment,extra, position is
+//	         available as well
+type markdownFormatter struct {
+	*template.Template
+	labels  map[string]string
+	toc     bool
+	anchor  string
+	columns []string
+}
+
+type markdownData struct {
+	L           map[string]string
+	TOC         bool
+	AnchorStyle string
+	TopAnchor   string
+	Columns     []string
+	Schemas     []*markdownSchema
+}
+
+type markdownSchema struct {
+	Name   string
+	Anchor string
+	Tables []*markdownTable
+}
+
+type markdownTable struct {
+	*model.Table
+	Title  string
+	Anchor string
+}
+
+func (f *markdownFormatter) Initialize(data []byte) error {
+	opts, err := parseOptions(data, "lang", "toc", "anchor", "columns")
+	if err != nil {
+		return err
+	}
+	lang := opts.String("lang", "zh")
+	if f.labels = labels[lang]; f.labels == nil {
+		return fmt.Errorf("unsupported lang %q", lang)
+	}
+	if f.toc, err = opts.Bool("toc", true); err != nil {
+		return err
+	}
+	switch f.anchor = opts.String("anchor", "github"); f.anchor {
+	case "github", "html", "none":
+	default:
+		return fmt.Errorf("unsupported anchor %q", f.anchor)
+	}
+	if f.columns, err = dictionaryColumns(opts); err != nil {
+		return err
+	}
+
+	text, err := assets.FS.ReadFile("markdown.tmpl")
+	if err != nil {
+		return err
+	}
+	f.Template, err = template.New("markdown").Funcs(template.FuncMap{
+		"cell":   f.cell,
+		"link":   f.link,
+		"anchor": f.anchorTag,
+	}).Parse(string(text))
+	return err
+}
+
+func (f *markdownFormatter) Format(val interface{}) ([]byte, error) {
+	tables, ok := val.(map[string][]*model.Table)
+	if !ok {
+		return nil, fmt.Errorf("markdown formatter can not format %T", val)
+	}
+
+	data := &markdownData{L: f.labels, TOC: f.toc, AnchorStyle: f.anchor, Columns: f.columns}
+	slugs := make(map[string]int)
+	anchor := func(title string) string {
+		if f.anchor == "none" {
+			return ""
+		}
+		return slug(title, slugs)
+	}
+	if f.toc {
+		data.TopAnchor = anchor(f.labels["toc"])
+	}
+
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		schema := &markdownSchema{Name: name, Anchor: anchor(f.labels["database"] + name)}
+		for _, t := range tables[name] {
+			title := t.TableName
+			if t.IsView() {
+				title += f.labels["("] + f.labels["view"] + f.labels[")"]
+			}
+			if t.TableComment != "" {
+				title += f.labels["("] + t.TableComment + f.labels[")"]
+			}
+			schema.Tables = append(schema.Tables, &markdownTable{Table: t, Title: title, Anchor: anchor(title)})
+		}
+		data.Schemas = append(data.Schemas, schema)
+	}
+
+	buffer := &bytes.Buffer{}
+	if err := f.Template.Execute(buffer, data); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// cell renders an attribute of column c as table cell.
+func (f *markdownFormatter) cell(name string, c *model.Column) string {
+	return markdownEscape(columnValue(name, c, f.labels))
+}
+
+// link renders a link to the anchor, or the plain text if anchors are disabled.
+func (f *markdownFormatter) link(anchor, text string) string {
+	if anchor == "" {
+		return text
+	}
+	return "[" + text + "](#" + anchor + ")"
+}
+
+// anchorTag declares the anchor if the anchor style is html.
+func (f *markdownFormatter) anchorTag(anchor string) string {
+	if f.anchor != "html" || anchor == "" {
+		return ""
+	}
+	return `<a id="` + anchor + `"></a>` + "\n"
+}
+
+// slug returns the heading id GitHub generates for title: lower case, spaces
+// replaced by '-' and punctuation removed. Repeated ids are suffixed by -1, -2 ...
+func slug(title string, seen map[string]int) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case r == ' ':
+			b.WriteRune('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+			b.WriteRune(r)
+		}
+	}
+	s := b.String()
+	n := seen[s]
+	seen[s]++
+	if n > 0 {
+		s = fmt.Sprintf("%v-%d", s, n)
+	}
+	return s
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
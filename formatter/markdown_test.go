@@ -0,0 +1,55 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func TestMarkdownFormatter(t *testing.T) {
+	f, err := NewFormatter("markdown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Initialize([]byte("lang=en\ncolumns=name,nullable,comment")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := f.Format(map[string][]*model.Table{
+		"shop": {
+			{TableName: "users", TableComment: "Users", Columns: []*model.Column{
+				{ColumnName: "id", IsNullable: "N"},
+				{ColumnName: "name", IsNullable: "YES", ColumnComment: "a|b\nc"},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"# Contents\n\n- [Database shop](#database-shop)\n  - [users (Users)](#users-users)\n",
+		"### users (Users)\n\n[*Back to top -->*](#contents)\n",
+		"| Name | Nullable | Comment |\n| :---: | :---: | :---: |\n| id | NO |  |\n| name | YES | a\\|b<br>c |\n",
+	} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("%q not found in\n%s", expected, data)
+		}
+	}
+
+	if err := f.Initialize([]byte("lang=fr")); err == nil {
+		t.Error("expected unsupported lang")
+	}
+}
+
+func TestSlug(t *testing.T) {
+	seen := make(map[string]int)
+	for _, c := range []struct{ title, expected string }{
+		{"users（用户）", "users用户"},
+		{"users（用户）", "users用户-1"},
+		{"Order_Items v2!", "order_items-v2"},
+	} {
+		if s := slug(c.title, seen); s != c.expected {
+			t.Errorf("slug %q = %q, expected %q", c.title, s, c.expected)
+		}
+	}
+}
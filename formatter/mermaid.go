@@ -0,0 +1,191 @@
+package formatter
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func init() {
+	RegisterFormatter("mermaid", func() Formatter {
+		return &mermaidFormatter{}
+	})
+}
+
+// mermaidFormatter renders tables as mermaid erDiagram, foreign keys are
+// drawn as relationships from the referenced table.
+//
+// Format config:
+//
+//	fence     wrap the diagram in a ```mermaid block, default true
+//	comments  render column comments, default true
+type mermaidFormatter struct {
+	fence    bool
+	comments bool
+}
+
+func (f *mermaidFormatter) Initialize(data []byte) error {
+	opts, err := parseOptions(data, "fence", "comments")
+	if err != nil {
+		return err
+	}
+	if f.fence, err = opts.Bool("fence", true); err != nil {
+		return err
+	}
+	f.comments, err = opts.Bool("comments", true)
+	return err
+}
+
+func (f *mermaidFormatter) Format(val interface{}) ([]byte, error) {
+	tables, ok := val.(map[string][]*model.Table)
+	if !ok {
+		return nil, fmt.Errorf("mermaid formatter can not format %T", val)
+	}
+
+	schemas := make([]string, 0, len(tables))
+	for schema := range tables {
+		schemas = append(schemas, schema)
+	}
+	sort.Strings(schemas)
+	// names are only qualified if there are several schemas
+	entity := func(schema, table string) string {
+		if len(schemas) > 1 {
+			return mermaidName(schema + "_" + table)
+		}
+		return mermaidName(table)
+	}
+
+	var b strings.Builder
+	if f.fence {
+		b.WriteString("```mermaid\n")
+	}
+	b.WriteString("erDiagram\n")
+	var relationships []string
+	for _, schema := range schemas {
+		for _, t := range tables[schema] {
+			name := entity(schema, t.TableName)
+			b.WriteString("    " + name + " {\n")
+			for _, c := range t.Columns {
+				b.WriteString("        " + mermaidType(c) + " " + mermaidName(c.ColumnName))
+				if keys := columnKeys(t, c.ColumnName); len(keys) > 0 {
+					b.WriteString(" " + strings.Join(keys, ", "))
+				}
+				if f.comments && c.ColumnComment != "" {
+					b.WriteString(` "` + mermaidComment(c.ColumnComment) + `"`)
+				}
+				b.WriteString("\n")
+			}
+			b.WriteString("    }\n")
+
+			for _, fk := range t.ForeignKeys() {
+				refSchema := fk.ReferencedTableSchema
+				if refSchema == "" {
+					refSchema = schema
+				}
+				relationships = append(relationships, fmt.Sprintf("    %v %v %v : %q\n",
+					entity(refSchema, fk.ReferencedTableName), cardinality(t, fk), name,
+					mermaidComment(fk.ConstraintName)))
+			}
+		}
+	}
+	for _, r := range relationships {
+		b.WriteString(r)
+	}
+	if f.fence {
+		b.WriteString("```\n")
+	}
+	return []byte(strings.TrimSuffix(b.String(), "\n")), nil
+}
+
+// columnKeys returns the PK, FK and UK markers of the column.
+func columnKeys(t *model.Table, column string) []string {
+	var keys []string
+	for _, key := range []struct{ constraintType, marker string }{
+		{model.PrimaryKey, "PK"},
+		{model.ForeignKey, "FK"},
+		{model.Unique, "UK"},
+	} {
+		for _, c := range t.Constraints {
+			if c.ConstraintType == key.constraintType && contains(c.Columns, column) {
+				keys = append(keys, key.marker)
+				break
+			}
+		}
+	}
+	return keys
+}
+
+// cardinality returns the relationship of the referenced table to t by fk.
+// The referenced row is optional if a column of fk is nullable, and t has at
+// most one row per referenced row if the columns of fk are unique in t. The
+// relationship is identifying, i.e. drawn solid, if fk is part of the primary key.
+func cardinality(t *model.Table, fk *model.Constraint) string {
+	left := "||"
+	for _, name := range fk.Columns {
+		if c := t.Column(name); c != nil && c.Nullable() {
+			left = "|o"
+		}
+	}
+
+	right := "o{"
+	for _, c := range t.Constraints {
+		if (c.ConstraintType == model.PrimaryKey || c.ConstraintType == model.Unique) && sameColumns(c.Columns, fk.Columns) {
+			right = "o|"
+		}
+	}
+
+	line := ".."
+	if pk := t.PrimaryKey(); pk != nil {
+		line = "--"
+		for _, name := range fk.Columns {
+			if !contains(pk.Columns, name) {
+				line = ".."
+			}
+		}
+	}
+	return left + line + right
+}
+
+// sameColumns reports whether a and b hold the same columns in any order.
+func sameColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, c := range a {
+		if !contains(b, c) {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	mermaidInvalidName = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+	mermaidInvalidType = regexp.MustCompile(`[^A-Za-z0-9_()\[\]-]`)
+)
+
+// mermaidName replaces the characters mermaid does not accept in names.
+func mermaidName(name string) string {
+	return mermaidInvalidName.ReplaceAllString(name, "_")
+}
+
+// mermaidType returns the type of c in the characters mermaid accepts, e.g.
+// decimal(10-2) for decimal(10,2).
+func mermaidType(c *model.Column) string {
+	t := c.ColumnType
+	if t == "" {
+		t = c.DataType
+	}
+	if t == "" {
+		return "unknown"
+	}
+	t = strings.ReplaceAll(strings.ReplaceAll(t, ", ", "-"), ",", "-")
+	return mermaidInvalidType.ReplaceAllString(t, "_")
+}
+
+func mermaidComment(comment string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(comment, `"`, "'"), "\n", " ")
+}
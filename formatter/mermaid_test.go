@@ -0,0 +1,52 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func TestMermaidFormatter(t *testing.T) {
+	f, err := NewFormatter("mermaid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Initialize([]byte("fence=false")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := f.Format(map[string][]*model.Table{
+		"shop": {
+			{TableName: "users", Columns: []*model.Column{
+				{ColumnName: "id", ColumnType: "bigint", IsNullable: "NO"},
+				{ColumnName: "email", ColumnType: "varchar(64)", IsNullable: "NO", ColumnComment: `"login" name`},
+			}, Constraints: []*model.Constraint{
+				{ConstraintName: "PRIMARY", ConstraintType: model.PrimaryKey, Columns: []string{"id"}},
+				{ConstraintName: "uk_email", ConstraintType: model.Unique, Columns: []string{"email"}},
+			}},
+			{TableName: "user profiles", Columns: []*model.Column{
+				{ColumnName: "user_id", ColumnType: "bigint", IsNullable: "NO"},
+				{ColumnName: "score", ColumnType: "decimal(10,2)", IsNullable: "YES"},
+			}, Constraints: []*model.Constraint{
+				{ConstraintName: "PRIMARY", ConstraintType: model.PrimaryKey, Columns: []string{"user_id"}},
+				{ConstraintName: "fk_user", ConstraintType: model.ForeignKey, Columns: []string{"user_id"},
+					ReferencedTableName: "users", ReferencedColumns: []string{"id"}},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `erDiagram
+    users {
+        bigint id PK
+        varchar(64) email UK "'login' name"
+    }
+    user_profiles {
+        bigint user_id PK, FK
+        decimal(10-2) score
+    }
+    users ||--o| user_profiles : "fk_user"`
+	if string(data) != expected {
+		t.Errorf("unexpected diagram\n%s", data)
+	}
+}
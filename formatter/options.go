@@ -0,0 +1,63 @@
+package formatter
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// options holds the format config of formatters configured by key=value pairs,
+// e.g. "dialect=pgsql&qualify=true". Pairs may also be separated by newlines,
+// so that they can be kept in a file given as @filename.
+type options url.Values
+
+// parseOptions parses the format config accepting only the keys, so that a
+// misspelled key is not ignored silently.
+func parseOptions(data []byte, keys ...string) (options, error) {
+	var pairs []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			pairs = append(pairs, line)
+		}
+	}
+	values, err := url.ParseQuery(strings.Join(pairs, "&"))
+	if err != nil {
+		return nil, fmt.Errorf("parse format config failed, %w", err)
+	}
+	var unknown []string
+	for key := range values {
+		if !contains(keys, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown format config %v, expected %v", strings.Join(unknown, ", "),
+			strings.Join(keys, ", "))
+	}
+	return options(values), nil
+}
+
+// String returns the value of key, or def if it is not set.
+func (o options) String(key, def string) string {
+	if v := url.Values(o).Get(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// Bool returns the value of key as bool, or def if it is not set.
+func (o options) Bool(key string, def bool) (bool, error) {
+	v := url.Values(o).Get(key)
+	if v == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %v option %q, %w", key, v, err)
+	}
+	return b, nil
+}
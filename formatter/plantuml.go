@@ -0,0 +1,99 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func init() {
+	RegisterFormatter("plantuml", func() Formatter {
+		return &plantumlFormatter{}
+	})
+}
+
+// plantumlFormatter renders tables as PlantUML entities in the information
+// engineering notation, the primary key columns are listed above the line and
+// mandatory columns are marked with *. See graphFormatter for the format config.
+type plantumlFormatter struct {
+	graphFormatter
+}
+
+func (f *plantumlFormatter) Initialize(data []byte) error {
+	return f.initialize(data)
+}
+
+func (f *plantumlFormatter) Format(val interface{}) ([]byte, error) {
+	g, err := f.build("plantuml", val)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	b.WriteString("@startuml\n")
+	b.WriteString("hide circle\n")
+	b.WriteString("skinparam linetype ortho\n")
+	for _, schema := range g.schemas {
+		indent := ""
+		if f.cluster {
+			fmt.Fprintf(&b, "package %q {\n", schema)
+			indent = "    "
+		}
+		for _, t := range g.tables[schema] {
+			columns := f.visibleColumns(t)
+			fmt.Fprintf(&b, "%ventity %q as %v", indent, t.TableName, mermaidName(g.ids[t]))
+			if len(columns) == 0 {
+				b.WriteString("\n")
+				continue
+			}
+			b.WriteString(" {\n")
+
+			var pk []string
+			if c := t.PrimaryKey(); c != nil {
+				pk = c.Columns
+			}
+			var keyLines, lines []string
+			for _, c := range columns {
+				line := plantumlColumn(t, c)
+				if contains(pk, c.ColumnName) {
+					keyLines = append(keyLines, line)
+				} else {
+					lines = append(lines, line)
+				}
+			}
+			for _, line := range keyLines {
+				b.WriteString(indent + "    " + line + "\n")
+			}
+			if len(keyLines) > 0 {
+				b.WriteString(indent + "    --\n")
+			}
+			for _, line := range lines {
+				b.WriteString(indent + "    " + line + "\n")
+			}
+			b.WriteString(indent + "}\n")
+		}
+		if f.cluster {
+			b.WriteString("}\n")
+		}
+	}
+
+	for _, e := range g.edges {
+		fmt.Fprintf(&b, "%v %v %v : %v\n", mermaidName(g.ids[e.to]), cardinality(e.from, e.fk),
+			mermaidName(g.ids[e.from]), e.fk.ConstraintName)
+	}
+	b.WriteString("@enduml")
+	return []byte(b.String()), nil
+}
+
+// plantumlColumn returns the line of column c, e.g. * id : bigint <<PK>>
+func plantumlColumn(t *model.Table, c *model.Column) string {
+	line := c.ColumnName + " : " + c.Type()
+	if !c.Nullable() {
+		line = "* " + line
+	}
+	for _, key := range columnKeys(t, c.ColumnName) {
+		line += " <<" + key + ">>"
+	}
+	return line
+}
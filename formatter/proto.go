@@ -0,0 +1,189 @@
+package formatter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func init() {
+	RegisterFormatter("proto", func() Formatter {
+		return &protoFormatter{}
+	})
+}
+
+// protoFormatter generates a proto3 message per table, nullable columns are
+// optional fields and enum columns get a nested enum.
+//
+// Format config:
+//
+//	package     package of the proto file, default model
+//	go_package  go_package option, not written by default
+type protoFormatter struct {
+	pkg       string
+	goPackage string
+}
+
+func (f *protoFormatter) Initialize(data []byte) error {
+	opts, err := parseOptions(data, "package", "go_package")
+	if err != nil {
+		return err
+	}
+	f.pkg = opts.String("package", "model")
+	for _, part := range strings.Split(f.pkg, ".") {
+		if protoName(part) != part {
+			return fmt.Errorf("invalid package %q", f.pkg)
+		}
+	}
+	f.goPackage = opts.String("go_package", "")
+	return nil
+}
+
+func (f *protoFormatter) Format(val interface{}) ([]byte, error) {
+	tables, ok := val.(map[string][]*model.Table)
+	if !ok {
+		return nil, fmt.Errorf("proto formatter can not format %T", val)
+	}
+	schemas := make([]string, 0, len(tables))
+	for schema := range tables {
+		schemas = append(schemas, schema)
+	}
+	sort.Strings(schemas)
+
+	var body strings.Builder
+	timestamp := false
+	for _, schema := range schemas {
+		for _, t := range tables[schema] {
+			name := camelCase(t.TableName)
+			if len(schemas) > 1 {
+				name = camelCase(schema + "_" + t.TableName)
+			}
+			body.WriteString("\n")
+			writeProtoComment(&body, "", t.TableComment)
+			fmt.Fprintf(&body, "message %v {\n", name)
+
+			var enums strings.Builder
+			seen := make(map[string]bool)
+			for n, c := range t.Columns {
+				field := protoName(snakeCase(c.ColumnName))
+				for i := 2; seen[field]; i++ {
+					field = fmt.Sprintf("%v_%d", protoName(snakeCase(c.ColumnName)), i)
+				}
+				seen[field] = true
+
+				typ := protoType(c)
+				if values := enumValues(c); len(values) > 0 {
+					typ = camelCase(c.ColumnName)
+					writeProtoEnum(&enums, typ, values)
+				}
+				timestamp = timestamp || typ == "google.protobuf.Timestamp"
+				if c.Nullable() {
+					typ = "optional " + typ
+				}
+
+				comment := c.ColumnComment
+				if n := c.MaxLength(); n > 0 {
+					comment = strings.TrimSpace(fmt.Sprintf("%v\nmax length: %d", comment, n))
+				}
+				writeProtoComment(&body, "  ", comment)
+				fmt.Fprintf(&body, "  %v %v = %d;\n", typ, field, n+1)
+			}
+			if enums.Len() > 0 {
+				body.WriteString(enums.String())
+			}
+			body.WriteString("}\n")
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("// Code generated by dbdump. DO NOT EDIT.\n\n")
+	b.WriteString("syntax = \"proto3\";\n\n")
+	b.WriteString("package " + f.pkg + ";\n")
+	if timestamp {
+		b.WriteString("\nimport \"google/protobuf/timestamp.proto\";\n")
+	}
+	if f.goPackage != "" {
+		fmt.Fprintf(&b, "\noption go_package = %q;\n", f.goPackage)
+	}
+	b.WriteString(body.String())
+	return []byte(strings.TrimSuffix(b.String(), "\n")), nil
+}
+
+// protoType returns the scalar type of the column.
+func protoType(c *model.Column) string {
+	switch c.GoType() {
+	case "bool":
+		return "bool"
+	case "int8", "int16", "int32":
+		return "int32"
+	case "uint8", "uint16", "uint32":
+		return "uint32"
+	case "int64":
+		return "int64"
+	case "uint64":
+		return "uint64"
+	case "float32":
+		return "float"
+	case "float64":
+		return "double"
+	case "time.Time":
+		return "google.protobuf.Timestamp"
+	case "[]byte":
+		return "bytes"
+	}
+	return "string"
+}
+
+// writeProtoEnum writes the nested enum, the values are prefixed by the enum
+// name as the style guide suggests and followed by the value in the database.
+func writeProtoEnum(b *strings.Builder, name string, values []string) {
+	prefix := strings.ToUpper(snakeCase(name)) + "_"
+	fmt.Fprintf(b, "\n  enum %v {\n", name)
+	fmt.Fprintf(b, "    %vUNSPECIFIED = 0;\n", prefix)
+	seen := map[string]bool{prefix + "UNSPECIFIED": true}
+	for i, v := range values {
+		value := prefix + protoChars(strings.ToUpper(snakeCase(v)))
+		if value == prefix {
+			value += "EMPTY"
+		}
+		unique := value
+		for n := 2; seen[unique]; n++ {
+			unique = fmt.Sprintf("%v_%d", value, n)
+		}
+		seen[unique] = true
+		fmt.Fprintf(b, "    %v = %d; // %q\n", unique, i+1, v)
+	}
+	b.WriteString("  }\n")
+}
+
+func writeProtoComment(b *strings.Builder, indent, comment string) {
+	for _, line := range strings.Split(comment, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			b.WriteString(indent + "// " + line + "\n")
+		}
+	}
+}
+
+// protoName replaces the characters not allowed in proto identifiers, and
+// prefixes names not starting with a letter.
+func protoName(name string) string {
+	name = protoChars(name)
+	if name == "" || !unicode.IsLetter(rune(name[0])) {
+		return "f_" + name
+	}
+	return name
+}
+
+// protoChars replaces the characters other than ASCII letters, digits and
+// underscores by underscores.
+func protoChars(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return '_'
+		}
+		return r
+	}, name)
+}
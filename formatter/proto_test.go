@@ -0,0 +1,64 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func TestProtoFormatter(t *testing.T) {
+	f, err := NewFormatter("proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Initialize([]byte("package=shop.v1&go_package=example.com/shop/v1")); err != nil {
+		t.Fatal(err)
+	}
+	tables := map[string][]*model.Table{"shop": {
+		{TableSchema: "shop", TableName: "user_accounts", TableComment: "用户", Columns: []*model.Column{
+			{ColumnName: "id", DataType: "bigint", ColumnType: "bigint unsigned", IsNullable: "NO", Extra: "auto_increment"},
+			{ColumnName: "team_id", DataType: "int", ColumnType: "int(11)", IsNullable: "YES"},
+			{ColumnName: "homeURL", DataType: "varchar", ColumnType: "varchar(255)", IsNullable: "YES", ColumnComment: "主页"},
+			{ColumnName: "enabled", DataType: "tinyint", ColumnType: "tinyint(1)", IsNullable: "NO"},
+			{ColumnName: "created_at", DataType: "datetime", ColumnType: "datetime", IsNullable: "NO"},
+			{ColumnName: "avatar", DataType: "blob", ColumnType: "blob", IsNullable: "YES"},
+			{ColumnName: "status", DataType: "enum", ColumnType: "enum('active','in-review','')", IsNullable: "YES"},
+		}},
+	}}
+	data, err := f.Format(tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `// Code generated by dbdump. DO NOT EDIT.
+
+syntax = "proto3";
+
+package shop.v1;
+
+import "google/protobuf/timestamp.proto";
+
+option go_package = "example.com/shop/v1";
+
+// 用户
+message UserAccounts {
+  uint64 id = 1;
+  optional int32 team_id = 2;
+  // 主页
+  // max length: 255
+  optional string home_url = 3;
+  bool enabled = 4;
+  google.protobuf.Timestamp created_at = 5;
+  optional bytes avatar = 6;
+  optional Status status = 7;
+
+  enum Status {
+    STATUS_UNSPECIFIED = 0;
+    STATUS_ACTIVE = 1; // "active"
+    STATUS_IN_REVIEW = 2; // "in-review"
+    STATUS_EMPTY = 3; // ""
+  }
+}`
+	if string(data) != expected {
+		t.Errorf("unexpected proto\n%s", data)
+	}
+}
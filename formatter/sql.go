@@ -0,0 +1,58 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Nutao/dbdump/ddl"
+	"github.com/Nutao/dbdump/model"
+)
+
+func init() {
+	RegisterFormatter("sql", func() Formatter {
+		return &sqlFormatter{}
+	})
+}
+
+// sqlFormatter renders tables as CREATE TABLE statements.
+//
+// Format config:
+//
+//	dialect  mysql, pgsql or sqlite, default the db type the tables are
+//	         loaded from
+//	qualify  prefix table names with their schema, default false
+type sqlFormatter struct {
+	*ddl.Generator
+	source string
+}
+
+// SetSource sets the db type the tables are loaded from, which decides how
+// the defaults are rendered and is the default dialect.
+func (f *sqlFormatter) SetSource(dbType string) {
+	f.source = dbType
+}
+
+func (f *sqlFormatter) Initialize(data []byte) error {
+	opts, err := parseOptions(data, "dialect", "qualify")
+	if err != nil {
+		return err
+	}
+	qualify, err := opts.Bool("qualify", false)
+	if err != nil {
+		return err
+	}
+	source := f.source
+	if source == "" {
+		source = "mysql"
+	}
+	f.Generator, err = ddl.NewGenerator(opts.String("dialect", source), ddl.Options{Qualify: qualify, Source: source})
+	return err
+}
+
+func (f *sqlFormatter) Format(val interface{}) ([]byte, error) {
+	tables, ok := val.(map[string][]*model.Table)
+	if !ok {
+		return nil, fmt.Errorf("sql formatter can not format %T", val)
+	}
+	return []byte(strings.Join(f.Generate(tables), "\n\n")), nil
+}
@@ -0,0 +1,62 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func TestSQLFormatterSource(t *testing.T) {
+	tables := map[string][]*model.Table{
+		"public": {{TableSchema: "public", TableName: "users", Columns: []*model.Column{
+			{ColumnName: "name", DataType: "varchar", ColumnType: "varchar(32)", IsNullable: "N",
+				ColumnDefault: "'x'::character varying"},
+			{ColumnName: "tags", DataType: "_text", ColumnType: "_text", IsNullable: "Y", ColumnDefaultNull: true},
+		}}},
+	}
+	tests := []struct {
+		config   string
+		expected string
+	}{
+		{"", `CREATE TABLE "users" (
+  "name" varchar(32) NOT NULL DEFAULT 'x'::character varying,
+  "tags" _text
+);`},
+		{"dialect=mysql", "CREATE TABLE `users` (\n" +
+			"  `name` varchar(32) NOT NULL DEFAULT 'x',\n" +
+			"  `tags` text NULL\n" +
+			");"},
+	}
+	for _, tt := range tests {
+		f, err := NewFormatter("sql")
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.(SourceFormatter).SetSource("pgsql")
+		if err := f.Initialize([]byte(tt.config)); err != nil {
+			t.Fatal(err)
+		}
+		data, err := f.Format(tables)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.TrimSpace(string(data)); got != tt.expected {
+			t.Errorf("config %q: unexpected output\n%s", tt.config, got)
+		}
+	}
+}
+
+func TestUnknownOption(t *testing.T) {
+	for _, name := range []string{"sql", "markdown", "csv", "xlsx", "html", "mermaid", "dot", "plantuml", "gostruct",
+		"proto", "jsonschema", "json", "yaml", "toml"} {
+		f, err := NewFormatter(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = f.Initialize([]byte("dialcet=pgsql"))
+		if err == nil || !strings.Contains(err.Error(), "unknown format config dialcet") {
+			t.Errorf("%v: unexpected error %v", name, err)
+		}
+	}
+}
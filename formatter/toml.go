@@ -0,0 +1,92 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/BurntSushi/toml"
+)
+
+func init() {
+	RegisterFormatter("toml", func() Formatter {
+		return &tomlFormatter{}
+	})
+}
+
+// tomlFormatter renders the value as toml, lists of objects are rendered as
+// arrays of tables, e.g. [[schema.Columns]]. Null values are left out as toml
+// has no null. See treeOptions for the format config.
+type tomlFormatter struct {
+	treeOptions
+}
+
+func (f *tomlFormatter) Initialize(data []byte) error {
+	opts, err := parseOptions(data, treeKeys...)
+	if err != nil {
+		return err
+	}
+	return f.initialize(opts)
+}
+
+func (f *tomlFormatter) Format(val interface{}) ([]byte, error) {
+	tree, err := f.tree(val)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := tree.(orderedMap); !ok {
+		return nil, errors.New("toml formatter can only format objects")
+	}
+	var b bytes.Buffer
+	enc := toml.NewEncoder(&b)
+	enc.Indent = ""
+	if err := enc.Encode(tomlValue(tree)); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(b.Bytes()), nil
+}
+
+// tomlValue returns node as a value of the toml encoder. An object becomes a
+// struct whose fields are tagged by the keys, since the encoder sorts the keys
+// of maps but keeps the order of struct fields.
+func tomlValue(node interface{}) interface{} {
+	switch n := node.(type) {
+	case orderedMap:
+		var fields []reflect.StructField
+		var values []reflect.Value
+		for _, item := range n {
+			if item.Value == nil {
+				continue
+			}
+			v := reflect.ValueOf(tomlValue(item.Value))
+			fields = append(fields, reflect.StructField{
+				Name: fmt.Sprintf("F%d", len(fields)),
+				Type: v.Type(),
+				Tag:  reflect.StructTag(fmt.Sprintf("toml:%q", item.Key)),
+			})
+			values = append(values, v)
+		}
+		s := reflect.New(reflect.StructOf(fields)).Elem()
+		for i, v := range values {
+			s.Field(i).Set(v)
+		}
+		return s.Interface()
+	case []interface{}:
+		list := []interface{}{}
+		for _, v := range n {
+			if v != nil {
+				list = append(list, tomlValue(v))
+			}
+		}
+		return list
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return i
+		}
+		f, _ := n.Float64()
+		return f
+	}
+	return node
+}
@@ -0,0 +1,220 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+// The structured formatters (json, yaml and toml) render a tree decoded from
+// the json encoding of the value, which keeps the order of the struct fields.
+// The nodes are orderedMap, []interface{}, string, json.Number, bool or nil.
+type orderedMap []mapItem
+
+type mapItem struct {
+	Key   string
+	Value interface{}
+}
+
+// MarshalJSON encodes the map as a json object keeping the order of the items.
+func (m orderedMap) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, item := range m {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(item.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(item.Value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// treeOptions holds the format config shared by the structured formatters.
+//
+// Format config:
+//
+//	fields     comma separated fields of the tables to keep, nested fields are
+//	           separated by dots, e.g. TableName,Columns.ColumnName, default all
+//	omitempty  drop the fields of empty values, i.e. "", 0, false, null and
+//	           empty lists, default false
+type treeOptions struct {
+	fields    [][]string
+	omitEmpty bool
+}
+
+// treeKeys are the format config keys of treeOptions.
+var treeKeys = []string{"fields", "omitempty"}
+
+func (o *treeOptions) initialize(opts options) error {
+	o.fields = nil
+	if fields := opts.String("fields", ""); fields != "" {
+		for _, field := range strings.Split(fields, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				o.fields = append(o.fields, strings.Split(field, "."))
+			}
+		}
+	}
+	var err error
+	o.omitEmpty, err = opts.Bool("omitempty", false)
+	return err
+}
+
+// selected reports whether the tree is changed by the options.
+func (o *treeOptions) selected() bool {
+	return len(o.fields) > 0 || o.omitEmpty
+}
+
+// tree returns the tree of val with the fields selected.
+func (o *treeOptions) tree(val interface{}) (interface{}, error) {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	tree, err := decodeTree(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(o.fields) > 0 {
+		if _, ok := val.(map[string][]*model.Table); ok {
+			// fields are relative to the tables in the schemas
+			m := tree.(orderedMap)
+			for i := range m {
+				m[i].Value = selectFields(m[i].Value, o.fields)
+			}
+		} else {
+			tree = selectFields(tree, o.fields)
+		}
+	}
+	if o.omitEmpty {
+		tree = omitEmpty(tree)
+	}
+	return tree, nil
+}
+
+func decodeTree(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		m := orderedMap{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeTree(dec)
+			if err != nil {
+				return nil, err
+			}
+			m = append(m, mapItem{Key: key.(string), Value: value})
+		}
+		_, err = dec.Token()
+		return m, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for dec.More() {
+			value, err := decodeTree(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err = dec.Token()
+		return list, err
+	}
+	return tok, nil
+}
+
+// selectFields keeps the fields of the objects in node matching the paths.
+func selectFields(node interface{}, paths [][]string) interface{} {
+	switch n := node.(type) {
+	case []interface{}:
+		result := make([]interface{}, len(n))
+		for i, v := range n {
+			result[i] = selectFields(v, paths)
+		}
+		return result
+	case orderedMap:
+		result := orderedMap{}
+		for _, item := range n {
+			var sub [][]string
+			whole := false
+			for _, path := range paths {
+				if path[0] != item.Key {
+					continue
+				}
+				if len(path) == 1 {
+					whole = true
+				} else {
+					sub = append(sub, path[1:])
+				}
+			}
+			switch {
+			case whole:
+				result = append(result, item)
+			case len(sub) > 0:
+				result = append(result, mapItem{Key: item.Key, Value: selectFields(item.Value, sub)})
+			}
+		}
+		return result
+	}
+	return node
+}
+
+// omitEmpty drops the fields of empty values in the objects of node.
+func omitEmpty(node interface{}) interface{} {
+	switch n := node.(type) {
+	case []interface{}:
+		result := make([]interface{}, len(n))
+		for i, v := range n {
+			result[i] = omitEmpty(v)
+		}
+		return result
+	case orderedMap:
+		result := orderedMap{}
+		for _, item := range n {
+			if value := omitEmpty(item.Value); !isEmpty(value) {
+				result = append(result, mapItem{Key: item.Key, Value: value})
+			}
+		}
+		return result
+	}
+	return node
+}
+
+func isEmpty(node interface{}) bool {
+	switch n := node.(type) {
+	case nil:
+		return true
+	case string:
+		return n == ""
+	case bool:
+		return !n
+	case json.Number:
+		f, err := n.Float64()
+		return err == nil && f == 0
+	case []interface{}:
+		return len(n) == 0
+	case orderedMap:
+		return len(n) == 0
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func treeTables() map[string][]*model.Table {
+	return map[string][]*model.Table{
+		"shop": {
+			{TableSchema: "shop", TableName: "users", TableComment: "用户: <all>", Columns: []*model.Column{
+				{ColumnName: "id", OrdinalPosition: 1, IsNullable: "NO", ColumnType: "int(11)"},
+				{ColumnName: "on", OrdinalPosition: 2, IsNullable: "YES", ColumnType: "tinyint(1)"},
+			}, Constraints: []*model.Constraint{
+				{ConstraintName: "PRIMARY", Columns: []string{"id"}},
+			}},
+		},
+	}
+}
+
+func formatTree(t *testing.T, name, config string) string {
+	f, err := NewFormatter(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Initialize([]byte(config)); err != nil {
+		t.Fatal(err)
+	}
+	data, err := f.Format(treeTables())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestJSONFormatter(t *testing.T) {
+	fields := "fields=TableName,TableComment,Columns.ColumnName,Columns.OrdinalPosition,Constraints.Columns"
+	for config, expected := range map[string]string{
+		fields + "&indent=2": `{
+  "shop": [
+    {
+      "TableName": "users",
+      "TableComment": "用户: \u003call\u003e",
+      "Columns": [
+        {
+          "ColumnName": "id",
+          "OrdinalPosition": 1
+        },
+        {
+          "ColumnName": "on",
+          "OrdinalPosition": 2
+        }
+      ],
+      "Constraints": [
+        {
+          "Columns": [
+            "id"
+          ]
+        }
+      ]
+    }
+  ]
+}`,
+		fields + "&indent=0": `{"shop":[{"TableName":"users","TableComment":"用户: \u003call\u003e",` +
+			`"Columns":[{"ColumnName":"id","OrdinalPosition":1},{"ColumnName":"on","OrdinalPosition":2}],` +
+			`"Constraints":[{"Columns":["id"]}]}]}`,
+		"ndjson=true&omitempty=true&fields=TableSchema,TableName,Columns.ColumnName,Columns.ColumnDefault": `{"TableSchema":"shop","TableName":"users","Columns":[{"ColumnName":"id"},{"ColumnName":"on"}]}`,
+	} {
+		if data := formatTree(t, "json", config); data != expected {
+			t.Errorf("unexpected json of %q\n%s", config, data)
+		}
+	}
+}
+
+func TestYAMLFormatter(t *testing.T) {
+	data := formatTree(t, "yaml", "omitempty=true&fields=TableName,TableComment,Columns,Constraints.Columns")
+	expected := `shop:
+  - TableName: users
+    TableComment: '用户: <all>'
+    Columns:
+      - ColumnName: id
+        OrdinalPosition: 1
+        IsNullable: "NO"
+        ColumnType: int(11)
+      - ColumnName: "on"
+        OrdinalPosition: 2
+        IsNullable: "YES"
+        ColumnType: tinyint(1)
+    Constraints:
+      - Columns:
+          - id`
+	if data != expected {
+		t.Errorf("unexpected yaml\n%s", data)
+	}
+}
+
+func TestTOMLFormatter(t *testing.T) {
+	data := formatTree(t, "toml", "fields=TableName,Columns.ColumnName,Constraints")
+	expected := `[[shop]]
+TableName = "users"
+
+[[shop.Columns]]
+ColumnName = "id"
+
+[[shop.Columns]]
+ColumnName = "on"
+
+[[shop.Constraints]]
+ConstraintName = "PRIMARY"
+ConstraintType = ""
+TableSchema = ""
+TableName = ""
+Enforced = ""
+Columns = ["id"]
+ReferencedTableSchema = ""
+ReferencedTableName = ""
+UpdateRule = ""
+DeleteRule = ""
+MatchOption = ""`
+	if data != expected {
+		t.Errorf("unexpected toml\n%s", data)
+	}
+}
@@ -0,0 +1,315 @@
+package formatter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func init() {
+	RegisterFormatter("xlsx", func() Formatter {
+		return &xlsxFormatter{}
+	})
+}
+
+// xlsxFormatter renders an Excel workbook with an overview sheet linking to a
+// sheet per table, which lists the columns of the table.
+//
+// Format config:
+//
+//	lang     language of the texts, zh or en, default zh
+//	columns  comma separated attributes of the columns as the markdown format,
+//	         default name,type,key,nullable,default,comment,extra
+type xlsxFormatter struct {
+	labels  map[string]string
+	columns []string
+}
+
+// xlsxSheet is a worksheet, the first row is the bold header.
+type xlsxSheet struct {
+	name  string
+	rows  [][]interface{}   // string or int cells
+	links map[string]string // sheet names linked from cells, keyed by cell reference
+}
+
+// xlsxPart is a file in the xlsx package.
+type xlsxPart struct {
+	name, data string
+}
+
+// styles of the cells, indexes of cellXfs in xlsxStyles
+const (
+	xlsxNormal = iota
+	xlsxBold
+	xlsxLink
+)
+
+func (f *xlsxFormatter) Initialize(data []byte) error {
+	opts, err := parseOptions(data, "lang", "columns")
+	if err != nil {
+		return err
+	}
+	lang := opts.String("lang", "zh")
+	if f.labels = labels[lang]; f.labels == nil {
+		return fmt.Errorf("unsupported lang %q", lang)
+	}
+	f.columns, err = dictionaryColumns(opts)
+	return err
+}
+
+// Binary marks the workbook as binary output.
+func (f *xlsxFormatter) Binary() {}
+
+func (f *xlsxFormatter) Format(val interface{}) ([]byte, error) {
+	tables, ok := val.(map[string][]*model.Table)
+	if !ok {
+		return nil, fmt.Errorf("xlsx formatter can not format %T", val)
+	}
+	schemas := make([]string, 0, len(tables))
+	for schema := range tables {
+		schemas = append(schemas, schema)
+	}
+	sort.Strings(schemas)
+
+	overview := &xlsxSheet{
+		name: f.labels["overview"],
+		rows: [][]interface{}{{
+			strings.TrimSpace(f.labels["database"]), f.labels["table"], f.labels["tableComment"], f.labels["columnCount"],
+		}},
+		links: make(map[string]string),
+	}
+	sheets := []*xlsxSheet{overview}
+	seen := map[string]bool{strings.ToLower(overview.name): true}
+	header := make([]interface{}, 0, len(f.columns))
+	for _, name := range f.columns {
+		header = append(header, f.labels[name])
+	}
+	for _, schema := range schemas {
+		for _, t := range tables[schema] {
+			name := t.TableName
+			if len(schemas) > 1 {
+				name = schema + "." + name
+			}
+			sheet := &xlsxSheet{name: xlsxSheetName(name, seen), rows: [][]interface{}{header}}
+			for _, c := range t.Columns {
+				row := make([]interface{}, 0, len(f.columns))
+				for _, name := range f.columns {
+					if name == "position" {
+						row = append(row, int(c.OrdinalPosition))
+					} else {
+						row = append(row, columnValue(name, c, f.labels))
+					}
+				}
+				sheet.rows = append(sheet.rows, row)
+			}
+			sheets = append(sheets, sheet)
+
+			overview.rows = append(overview.rows, []interface{}{schema, t.TableName, t.TableComment, len(t.Columns)})
+			overview.links[xlsxCell(1, len(overview.rows)-1)] = sheet.name
+		}
+	}
+	return xlsxWorkbook(sheets)
+}
+
+// xlsxSheetName returns a unique valid sheet name for name: at most 31
+// characters without []:*?/\ and not quoted.
+func xlsxSheetName(name string, seen map[string]bool) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, "'")
+	if name == "" {
+		name = "_"
+	}
+	result := xlsxTruncate(name, 31)
+	for n := 2; seen[strings.ToLower(result)]; n++ {
+		suffix := "~" + strconv.Itoa(n)
+		result = xlsxTruncate(name, 31-len(suffix)) + suffix
+	}
+	seen[strings.ToLower(result)] = true
+	return result
+}
+
+func xlsxTruncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// xlsxCell returns the reference of the cell, e.g. B3 of column 1 and row 2
+// counted from 0.
+func xlsxCell(col, row int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name + strconv.Itoa(row+1)
+}
+
+// xlsxWorkbook packs the sheets as an xlsx file.
+func xlsxWorkbook(sheets []*xlsxSheet) ([]byte, error) {
+	var types, workbook, rels strings.Builder
+	types.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	workbook.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	rels.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+
+	parts := []xlsxPart{
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for i, sheet := range sheets {
+		n := strconv.Itoa(i + 1)
+		fmt.Fprintf(&types, `<Override PartName="/xl/worksheets/sheet%v.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&workbook, `<sheet name="%v" sheetId="%v" r:id="rId%v"/>`, xmlEscape(sheet.name), n, n)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%v" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%v.xml"/>`, n, n)
+		parts = append(parts, xlsxPart{"xl/worksheets/sheet" + n + ".xml", sheet.xml()})
+	}
+	types.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	fmt.Fprintf(&rels, `<Relationship Id="rId%v" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(sheets)+1)
+	rels.WriteString(`</Relationships>`)
+	parts = append([]xlsxPart{{"[Content_Types].xml", types.String()}}, parts...)
+	parts = append(parts,
+		xlsxPart{"xl/workbook.xml", workbook.String()},
+		xlsxPart{"xl/_rels/workbook.xml.rels", rels.String()})
+
+	buffer := &bytes.Buffer{}
+	w := zip.NewWriter(buffer)
+	for _, part := range parts {
+		fw, err := w.CreateHeader(&zip.FileHeader{Name: part.name, Method: zip.Deflate})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fw.Write([]byte(part.data)); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// xml renders the worksheet with the header row frozen.
+func (s *xlsxSheet) xml() string {
+	var b strings.Builder
+	b.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`)
+	b.WriteString(`<sheetViews><sheetView workbookViewId="0">` +
+		`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+
+	// the columns are as wide as their text, wide characters count twice
+	var widths []int
+	for _, row := range s.rows {
+		for i, v := range row {
+			width := 0
+			for _, r := range fmt.Sprint(v) {
+				if r > unicode.MaxLatin1 {
+					width += 2
+				} else {
+					width++
+				}
+			}
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+	if len(widths) > 0 {
+		b.WriteString("<cols>")
+		for i, width := range widths {
+			if width += 2; width < 8 {
+				width = 8
+			} else if width > 60 {
+				width = 60
+			}
+			fmt.Fprintf(&b, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, width)
+		}
+		b.WriteString("</cols>")
+	}
+
+	b.WriteString("<sheetData>")
+	for r, row := range s.rows {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		for c, v := range row {
+			ref := xlsxCell(c, r)
+			style := xlsxNormal
+			if r == 0 {
+				style = xlsxBold
+			} else if s.links[ref] != "" {
+				style = xlsxLink
+			}
+			switch v := v.(type) {
+			case int:
+				fmt.Fprintf(&b, `<c r="%v" s="%d"><v>%d</v></c>`, ref, style, v)
+			default:
+				fmt.Fprintf(&b, `<c r="%v" s="%d" t="inlineStr"><is><t xml:space="preserve">%v</t></is></c>`,
+					ref, style, xmlEscape(fmt.Sprint(v)))
+			}
+		}
+		b.WriteString("</row>")
+	}
+	b.WriteString("</sheetData>")
+
+	if len(s.links) > 0 {
+		refs := make([]string, 0, len(s.links))
+		for ref := range s.links {
+			refs = append(refs, ref)
+		}
+		sort.Strings(refs)
+		b.WriteString("<hyperlinks>")
+		for _, ref := range refs {
+			location := "'" + strings.ReplaceAll(s.links[ref], "'", "''") + "'!A1"
+			fmt.Fprintf(&b, `<hyperlink ref="%v" location="%v"/>`, ref, xmlEscape(location))
+		}
+		b.WriteString("</hyperlinks>")
+	}
+	b.WriteString("</worksheet>")
+	return b.String()
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// xlsxStyles declares the normal, bold and hyperlink cell styles.
+const xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="3">` +
+	`<font><sz val="11"/><name val="Calibri"/></font>` +
+	`<font><b/><sz val="11"/><name val="Calibri"/></font>` +
+	`<font><u/><sz val="11"/><color rgb="FF0563C1"/><name val="Calibri"/></font>` +
+	`</fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="3">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="0" fontId="2" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
@@ -0,0 +1,99 @@
+package formatter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func TestXLSXFormatter(t *testing.T) {
+	f, err := NewFormatter("xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Initialize(nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := f.Format(map[string][]*model.Table{
+		"shop": {
+			{TableName: "users", TableComment: "用户", Columns: []*model.Column{
+				{ColumnName: "id", ColumnType: "bigint", IsNullable: "NO"},
+				{ColumnName: "name", ColumnType: "varchar(32)", IsNullable: "YES", ColumnComment: "<姓名> & 昵称"},
+			}},
+			{TableName: "a_very_long_table_name_of_order_items"},
+			{TableName: "a_very_long_table_name_of_order_items_history"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := make(map[string]string)
+	for _, file := range r.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// every part is well-formed xml
+		dec := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			if _, err := dec.Token(); err != nil {
+				if err.Error() != "EOF" {
+					t.Errorf("invalid xml of %v, %v", file.Name, err)
+				}
+				break
+			}
+		}
+		parts[file.Name] = string(content)
+	}
+	if r.File[0].Name != "[Content_Types].xml" || len(parts) != 9 {
+		t.Errorf("unexpected parts %v", len(parts))
+	}
+
+	for _, s := range []string{
+		`<sheet name="概览" sheetId="1" r:id="rId1"/>`,
+		`<sheet name="users" sheetId="2" r:id="rId2"/>`,
+		`<sheet name="a_very_long_table_name_of_order" sheetId="3" r:id="rId3"/>`,
+		`<sheet name="a_very_long_table_name_of_ord~2" sheetId="4" r:id="rId4"/>`,
+	} {
+		if !strings.Contains(parts["xl/workbook.xml"], s) {
+			t.Errorf("expected %v in workbook\n%v", s, parts["xl/workbook.xml"])
+		}
+	}
+	overview := parts["xl/worksheets/sheet1.xml"]
+	for _, s := range []string{
+		`<c r="D1" s="1" t="inlineStr"><is><t xml:space="preserve">字段数</t></is></c>`,
+		`<c r="B2" s="2" t="inlineStr"><is><t xml:space="preserve">users</t></is></c>`,
+		`<c r="D2" s="0"><v>2</v></c>`,
+		`<hyperlink ref="B2" location="&#39;users&#39;!A1"/>`,
+	} {
+		if !strings.Contains(overview, s) {
+			t.Errorf("expected %v in overview\n%v", s, overview)
+		}
+	}
+	if s := `<t xml:space="preserve">&lt;姓名&gt; &amp; 昵称</t>`; !strings.Contains(parts["xl/worksheets/sheet2.xml"], s) {
+		t.Errorf("expected %v in sheet\n%v", s, parts["xl/worksheets/sheet2.xml"])
+	}
+}
+
+func TestXLSXCell(t *testing.T) {
+	for expected, cell := range map[string][2]int{"A1": {0, 0}, "Z10": {25, 9}, "AA2": {26, 1}, "AZ1": {51, 0}, "BA1": {52, 0}} {
+		if ref := xlsxCell(cell[0], cell[1]); ref != expected {
+			t.Errorf("xlsxCell(%v) = %v, expected %v", cell, ref, expected)
+		}
+	}
+}
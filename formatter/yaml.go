@@ -0,0 +1,83 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	RegisterFormatter("yaml", func() Formatter {
+		return &yamlFormatter{}
+	})
+}
+
+// yamlFormatter renders the value as block style yaml.
+// See treeOptions for the format config.
+type yamlFormatter struct {
+	treeOptions
+}
+
+func (f *yamlFormatter) Initialize(data []byte) error {
+	opts, err := parseOptions(data, treeKeys...)
+	if err != nil {
+		return err
+	}
+	return f.initialize(opts)
+}
+
+func (f *yamlFormatter) Format(val interface{}) ([]byte, error) {
+	tree, err := f.tree(val)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(yamlNode(tree)); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(b.Bytes()), nil
+}
+
+// yamlNode returns node as a yaml node, the mapping keeps the order of the
+// fields.
+func yamlNode(node interface{}) *yaml.Node {
+	switch n := node.(type) {
+	case orderedMap:
+		m := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, item := range n {
+			m.Content = append(m.Content, yamlNode(item.Key), yamlNode(item.Value))
+		}
+		return m
+	case []interface{}:
+		list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, v := range n {
+			list.Content = append(list.Content, yamlNode(v))
+		}
+		return list
+	case string:
+		// encoded as a value to quote the yaml 1.1 booleans, e.g. "NO"
+		var s yaml.Node
+		if err := s.Encode(n); err == nil {
+			return &s
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: n}
+	case json.Number:
+		if _, err := n.Int64(); err == nil {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: n.String()}
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: n.String()}
+	case bool:
+		value := "false"
+		if n {
+			value = "true"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: value}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+}
@@ -1,10 +1,14 @@
 module github.com/Nutao/dbdump
 
-go 1.14
+go 1.16
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/Masterminds/squirrel v1.5.0
 	github.com/go-sql-driver/mysql v1.5.0
 	github.com/lib/pq v1.10.2
+	github.com/mattn/go-sqlite3 v1.14.22
 	github.com/urfave/cli/v2 v2.3.0
+	golang.org/x/term v0.0.0-20210220032956-6a3ed077a48d
+	gopkg.in/yaml.v3 v3.0.1
 )
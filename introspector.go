@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+// Error define
+var (
+	ErrIntrospectorNotFound = errors.New("introspector not found")
+)
+
+var (
+	introspectors = make(map[string]func() Introspector)
+)
+
+// Introspector loads schema definitions from a database engine.
+//
+// Every loader returns its result keyed by schema (and table), so that one
+// shared pipeline can merge them into the Table model regardless of engine.
+type Introspector interface {
+	// Open connects to the database described by cfg.
+	Open(cfg *config) error
+	// Close releases the connection opened by Open.
+	Close() error
+
+	LoadTables() (map[string][]*model.Table, error)
+	// LoadColumns receives the tables returned by LoadTables, for engines
+	// which can only query columns table by table.
+	LoadColumns(tables map[string][]*model.Table) (map[string]map[string][]*model.Column, error)
+	LoadConstraints() (map[string]map[string]map[string]*model.Constraint, error)
+	// LoadIndexes loads indexes keyed by schema and table, in index name order.
+	LoadIndexes() (map[string]map[string][]*model.Index, error)
+}
+
+// RegisterIntrospector register an introspector for a db type
+func RegisterIntrospector(dbType string, create func() Introspector) {
+	introspectors[dbType] = create
+}
+
+// NewIntrospector creates an introspector by db type
+func NewIntrospector(dbType string) (Introspector, error) {
+	create := introspectors[dbType]
+	if create == nil {
+		return nil, fmt.Errorf("%v %w", dbType, ErrIntrospectorNotFound)
+	}
+	return create(), nil
+}
+
+// AllIntrospector returns all db types of registered introspector, sorted
+func AllIntrospector() []string {
+	names := make([]string, 0, len(introspectors))
+	for n := range introspectors {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
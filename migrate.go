@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Nutao/dbdump/ddl"
+	"github.com/Nutao/dbdump/model"
+	"github.com/urfave/cli/v2"
+)
+
+// Statement is a statement of a migration script.
+type Statement struct {
+	SQL         string
+	Destructive bool // the statement may lose data
+}
+
+// migrationStatements returns the statements applying d to the database it
+// was compared from. Foreign keys are dropped first and added last so that
+// the tables, columns and indexes they depend on may change in between.
+func migrationStatements(d *SchemaDiff, g *ddl.Generator) ([]Statement, error) {
+	alter, err := g.Alter()
+	if err != nil {
+		return nil, err
+	}
+
+	// views are not migrated since their definitions are not loaded
+	var tables []*TableDiff
+	for _, td := range d.Tables {
+		if (td.From == nil || !td.From.IsView()) && (td.To == nil || !td.To.IsView()) {
+			tables = append(tables, td)
+		}
+	}
+
+	var result []Statement
+	add := func(destructive bool, sqls ...string) {
+		for _, sql := range sqls {
+			result = append(result, Statement{SQL: sql, Destructive: destructive})
+		}
+	}
+	tableName := func(t *model.Table) string {
+		return g.TableName(t.TableSchema, t.TableName)
+	}
+
+	// drop foreign keys
+	for _, td := range tables {
+		switch td.Change {
+		case Removed:
+			for _, fk := range sortedForeignKeys(td.From) {
+				add(false, alter.DropConstraint(tableName(td.From), fk))
+			}
+		case Changed:
+			for _, cd := range td.Constraints {
+				if cd.Change != Added && cd.From.ConstraintType == model.ForeignKey {
+					add(false, alter.DropConstraint(tableName(td.From), cd.From))
+				}
+			}
+		}
+	}
+
+	// drop indexes and constraints
+	for _, td := range tables {
+		if td.Change != Changed {
+			continue
+		}
+		for _, id := range td.Indexes {
+			if id.Change != Added && td.From.Constraint(id.IndexName) == nil {
+				add(false, alter.DropIndex(g.Qualifier(td.From.TableSchema), tableName(td.From), id.From))
+			}
+		}
+		for _, cd := range td.Constraints {
+			if cd.Change != Added && cd.From.ConstraintType != model.ForeignKey {
+				add(false, alter.DropConstraint(tableName(td.From), cd.From))
+			}
+		}
+	}
+
+	// create tables
+	added := make(map[string][]*model.Table)
+	for _, td := range tables {
+		if td.Change == Added {
+			added[td.TableSchema] = append(added[td.TableSchema], td.To)
+		}
+	}
+	add(false, g.Generate(added)...)
+
+	// alter tables
+	for _, td := range tables {
+		if td.Change != Changed {
+			continue
+		}
+		for _, f := range td.Fields {
+			if f == "comment" {
+				add(false, alter.AlterTableComment(tableName(td.From), td.To)...)
+			}
+		}
+		for _, cd := range td.Columns {
+			switch cd.Change {
+			case Added:
+				add(false, g.AddColumn(td.From, cd.To))
+			case Changed:
+				// changing the type may truncate or fail to convert values
+				destructive := false
+				for _, f := range cd.Fields {
+					destructive = destructive || f == "type"
+				}
+				add(destructive, alter.AlterColumn(tableName(td.From), cd.From, cd.To)...)
+			}
+		}
+		for _, cd := range td.Constraints {
+			if cd.Change != Removed && cd.To.ConstraintType != model.ForeignKey {
+				add(false, addConstraint(g, td.From, cd.To))
+			}
+		}
+		for _, id := range td.Indexes {
+			if id.Change != Removed && td.To.Constraint(id.IndexName) == nil {
+				add(false, g.CreateIndex(tableName(td.From), id.To))
+			}
+		}
+	}
+
+	// add foreign keys
+	for _, td := range tables {
+		if td.Change != Changed {
+			continue
+		}
+		for _, cd := range td.Constraints {
+			if cd.Change != Removed && cd.To.ConstraintType == model.ForeignKey {
+				add(false, addConstraint(g, td.From, cd.To))
+			}
+		}
+	}
+
+	// drop columns and tables
+	for _, td := range tables {
+		if td.Change != Changed {
+			continue
+		}
+		for _, cd := range td.Columns {
+			if cd.Change == Removed {
+				add(true, g.DropColumn(td.From, cd.From))
+			}
+		}
+	}
+	for _, td := range tables {
+		if td.Change == Removed {
+			add(true, g.DropTable(td.From))
+		}
+	}
+
+	return result, nil
+}
+
+// addConstraint adds c, which is loaded from the source database, to table t
+// of the target database.
+func addConstraint(g *ddl.Generator, t *model.Table, c *model.Constraint) string {
+	target := *c
+	target.TableSchema = t.TableSchema
+	target.TableName = t.TableName
+	if c.ReferencedTableSchema == c.TableSchema {
+		target.ReferencedTableSchema = t.TableSchema
+	}
+	return g.AddConstraint(&target)
+}
+
+func sortedForeignKeys(t *model.Table) []*model.Constraint {
+	fks := t.ForeignKeys()
+	sort.Slice(fks, func(i, j int) bool { return fks[i].ConstraintName < fks[j].ConstraintName })
+	return fks
+}
+
+// renderMigration renders the statements as script, destructive statements
+// are preceded by a warning comment.
+func renderMigration(statements []Statement, alter ddl.AlterDialect, transaction bool, dialect string) string {
+	var b strings.Builder
+	if len(statements) == 0 {
+		b.WriteString("-- the schemas are identical, nothing to migrate\n")
+		return b.String()
+	}
+
+	wrap := transaction && alter.Transactional()
+	if transaction && !wrap {
+		fmt.Fprintf(&b, "-- %v commits DDL statements implicitly, the script is not wrapped in a transaction\n\n",
+			dialect)
+	}
+	if wrap {
+		b.WriteString("BEGIN;\n\n")
+	}
+	for _, s := range statements {
+		if s.Destructive {
+			b.WriteString("-- DESTRUCTIVE: the following statement may lose data\n")
+		}
+		b.WriteString(s.SQL + "\n\n")
+	}
+	if wrap {
+		b.WriteString("COMMIT;\n")
+	}
+	return b.String()
+}
+
+// migrateCommand generates the statements bringing the target database in
+// line with the database given by the global flags.
+func migrateCommand() *cli.Command {
+	target := &config{}
+	var dialect string
+	var transaction bool
+	return &cli.Command{
+		Name:  "migrate",
+		Usage: "Generate the statements migrating a target database to the schema of the database",
+		Flags: append(targetFlags(target),
+			&cli.StringFlag{
+				Name: "dialect",
+				Usage: fmt.Sprintf("Dialect of the statements(%v), default to the target db type.",
+					strings.Join(ddl.AllDialect(), "|")),
+				Destination: &dialect,
+			},
+			&cli.BoolFlag{
+				Name:        "transaction",
+				Usage:       "Wrap the statements in a transaction if the dialect supports transactional DDL.",
+				Destination: &transaction,
+			},
+		),
+		Action: func(ctx *cli.Context) error {
+			source, targetTables, err := introspectBoth(ctx, target)
+			if err != nil {
+				return err
+			}
+			if dialect == "" {
+				dialect = target.DBType
+			}
+
+			// names are only qualified if tables are matched by schema name
+			qualify := len(source) > 1 || len(targetTables) > 1
+			// the columns to create or alter are the ones of the source
+			g, err := ddl.NewGenerator(dialect, ddl.Options{Qualify: qualify, Source: gConfig.DBType})
+			if err != nil {
+				return err
+			}
+			alter, err := g.Alter()
+			if err != nil {
+				return fmt.Errorf("%v %w", dialect, err)
+			}
+			statements, err := migrationStatements(compareSchemas(targetTables, source), g)
+			if err != nil {
+				return err
+			}
+			return writeOutput([]byte(strings.TrimSuffix(renderMigration(statements, alter, transaction, dialect), "\n")))
+		},
+	}
+}
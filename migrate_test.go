@@ -0,0 +1,69 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Nutao/dbdump/ddl"
+	"github.com/Nutao/dbdump/model"
+)
+
+func TestMigrationStatements(t *testing.T) {
+	target := map[string][]*model.Table{
+		"shop": {
+			{TableSchema: "shop", TableName: "users", Columns: []*model.Column{
+				{ColumnName: "id", ColumnType: "int(11)", IsNullable: "NO", ColumnDefaultNull: true},
+				{ColumnName: "name", ColumnType: "varchar(32)", IsNullable: "YES", ColumnDefaultNull: true},
+				{ColumnName: "legacy", ColumnType: "int(11)", IsNullable: "YES", ColumnDefaultNull: true},
+			}, Constraints: []*model.Constraint{
+				{ConstraintName: "fk_team", ConstraintType: model.ForeignKey, TableSchema: "shop", TableName: "users",
+					Columns: []string{"legacy"}, ReferencedTableName: "teams", ReferencedColumns: []string{"id"}},
+			}, Indexes: []*model.Index{
+				{IndexName: "idx_name", Visible: true, Columns: []*model.IndexColumn{{ColumnName: "name"}}},
+			}},
+			{TableSchema: "shop", TableName: "teams", Columns: []*model.Column{
+				{ColumnName: "id", ColumnType: "int(11)", IsNullable: "NO", ColumnDefaultNull: true},
+			}},
+		},
+	}
+	source := map[string][]*model.Table{
+		"shop": {
+			{TableSchema: "shop", TableName: "users", Columns: []*model.Column{
+				{ColumnName: "id", ColumnType: "int(11)", IsNullable: "NO", ColumnDefaultNull: true},
+				{ColumnName: "name", ColumnType: "varchar(64)", IsNullable: "NO", ColumnDefaultNull: true},
+				{ColumnName: "email", ColumnType: "varchar(64)", IsNullable: "YES", ColumnDefaultNull: true},
+			}, Indexes: []*model.Index{
+				{IndexName: "idx_email", Unique: true, Visible: true, Columns: []*model.IndexColumn{{ColumnName: "email"}}},
+			}},
+		},
+	}
+
+	g, err := ddl.NewGenerator("mysql", ddl.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	statements, err := migrationStatements(compareSchemas(target, source), g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Statement{
+		{SQL: "ALTER TABLE `users` DROP FOREIGN KEY `fk_team`;"},
+		{SQL: "DROP INDEX `idx_name` ON `users`;"},
+		{SQL: "ALTER TABLE `users` MODIFY COLUMN `name` varchar(64) NOT NULL;", Destructive: true},
+		{SQL: "ALTER TABLE `users` ADD COLUMN `email` varchar(64) NULL;"},
+		{SQL: "CREATE UNIQUE INDEX `idx_email` ON `users` (`email`);"},
+		{SQL: "ALTER TABLE `users` DROP COLUMN `legacy`;", Destructive: true},
+		{SQL: "DROP TABLE `teams`;", Destructive: true},
+	}
+	if !reflect.DeepEqual(statements, expected) {
+		t.Errorf("unexpected statements %+v", statements)
+	}
+
+	g, err = ddl.NewGenerator("sqlite", ddl.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrationStatements(compareSchemas(target, source), g); err == nil {
+		t.Error("expected sqlite migrations to fail")
+	}
+}
@@ -0,0 +1,219 @@
+// Package model defines the schema model shared by the introspectors and formatters.
+package model
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Constraint holds constraints of table.
+type Constraint struct {
+	ConstraintName string
+	ConstraintType string
+	TableSchema    string
+	TableName      string
+	Enforced       string
+	Columns        []string
+
+	// Only set for FOREIGN KEY constraints, ReferencedColumns is ordered as Columns
+	ReferencedTableSchema string
+	ReferencedTableName   string
+	ReferencedColumns     []string
+	UpdateRule            string // CASCADE, SET NULL, SET DEFAULT, RESTRICT or NO ACTION
+	DeleteRule            string
+	MatchOption           string // NONE (i.e. MATCH SIMPLE), FULL or PARTIAL
+}
+
+// Column holds the definition of a table column.
+type Column struct {
+	TableCatalog      string
+	TableSchema       string
+	TableName         string
+	ColumnName        string
+	OrdinalPosition   uint32
+	ColumnDefaultNull bool
+	ColumnDefault     string
+	IsNullable        string
+	DataType          string
+	ColumnType        string
+	ColumnKey         string
+	Extra             string
+	ColumnComment     string
+}
+
+// IndexColumn holds a key part of an index.
+type IndexColumn struct {
+	ColumnName string
+	Expression string // expression of a functional key part, ColumnName is empty then
+	Order      string // ASC or DESC
+	SubPart    uint32 // indexed prefix length, 0 if the entire column is indexed
+}
+
+// Index holds the definition of a table index.
+type Index struct {
+	IndexName   string
+	TableSchema string
+	TableName   string
+	IndexType   string // BTREE, HASH, FULLTEXT, GIN ...
+	Unique      bool
+	Primary     bool
+	Predicate   string // WHERE clause of a partial index
+	Visible     bool   // whether the optimizer may use the index
+	Comment     string
+	Columns     []*IndexColumn
+}
+
+// Table holds the definition of a database table.
+type Table struct {
+	TableCatalog string
+	TableSchema  string
+	TableName    string
+	TableType    string
+	TableComment string
+
+	Columns     []*Column
+	Constraints []*Constraint
+	Indexes     []*Index
+}
+
+// UnknownExpression is the Expression of an IndexColumn whose expression text
+// can not be loaded from the database.
+const UnknownExpression = "<expression>"
+
+// Constraint types
+const (
+	PrimaryKey = "PRIMARY KEY"
+	Unique     = "UNIQUE"
+	ForeignKey = "FOREIGN KEY"
+)
+
+// Nullable reports whether the column accepts NULL. IS_NULLABLE is YES/NO in
+// information_schema while the pgsql introspector reports Y/N.
+func (c *Column) Nullable() bool {
+	return c.IsNullable != "NO" && c.IsNullable != "N"
+}
+
+// Type returns the full type of the column, e.g. varchar(32), or the data
+// type if the full type is not known.
+func (c *Column) Type() string {
+	if c.ColumnType != "" {
+		return c.ColumnType
+	}
+	return c.DataType
+}
+
+// GoType returns the Go type of the column ignoring its nullability, e.g.
+// int64 of bigint or uint32 of int unsigned.
+func (c *Column) GoType() string {
+	dataType := strings.ToLower(c.DataType)
+	columnType := strings.ToLower(c.ColumnType)
+	unsigned := strings.Contains(columnType, "unsigned")
+	integer := func(signed, unsignedType string) string {
+		if unsigned {
+			return unsignedType
+		}
+		return signed
+	}
+	switch dataType {
+	case "bool", "boolean":
+		return "bool"
+	case "tinyint":
+		if strings.HasPrefix(columnType, "tinyint(1)") {
+			return "bool"
+		}
+		return integer("int8", "uint8")
+	case "smallint", "int2", "smallserial", "year":
+		return integer("int16", "uint16")
+	case "mediumint", "int", "int4", "serial":
+		return integer("int32", "uint32")
+	case "bigint", "int8", "bigserial":
+		return integer("int64", "uint64")
+	case "integer":
+		// only reported by sqlite, whose integers are 64-bit
+		return "int64"
+	case "float", "float4", "real":
+		return "float32"
+	case "double", "double precision", "float8":
+		return "float64"
+	case "date", "datetime", "timestamp", "timestamptz", "timestamp with time zone", "timestamp without time zone":
+		return "time.Time"
+	case "binary", "varbinary", "blob", "tinyblob", "mediumblob", "longblob", "bytea", "bit":
+		return "[]byte"
+	case "decimal", "numeric", "money", "char", "varchar", "bpchar", "text", "tinytext", "mediumtext", "longtext",
+		"enum", "set", "json", "jsonb", "uuid", "xml", "inet", "cidr", "macaddr", "time", "timetz", "interval",
+		"citext", "character", "character varying", "clob":
+		// decimals are kept as strings to preserve the precision
+		return "string"
+	}
+	if strings.HasPrefix(dataType, "_") {
+		// postgres arrays are scanned in their text form
+		return "string"
+	}
+	return "interface{}"
+}
+
+// MaxLength returns the length of a character column, e.g. 64 of varchar(64),
+// or 0 if it is not limited.
+func (c *Column) MaxLength() int {
+	switch strings.ToLower(c.DataType) {
+	case "char", "varchar", "bpchar", "character", "character varying", "nchar", "nvarchar":
+	default:
+		return 0
+	}
+	t := c.Type()
+	start, end := strings.IndexByte(t, '('), strings.IndexByte(t, ')')
+	if start < 0 || end < start {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(t[start+1 : end]))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// Column returns the column with the name, or nil.
+func (t *Table) Column(name string) *Column {
+	for _, c := range t.Columns {
+		if c.ColumnName == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Constraint returns the constraint with the name, or nil.
+func (t *Table) Constraint(name string) *Constraint {
+	for _, c := range t.Constraints {
+		if c.ConstraintName == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// PrimaryKey returns the PRIMARY KEY constraint of the table, or nil.
+func (t *Table) PrimaryKey() *Constraint {
+	for _, c := range t.Constraints {
+		if c.ConstraintType == PrimaryKey {
+			return c
+		}
+	}
+	return nil
+}
+
+// ForeignKeys returns the FOREIGN KEY constraints of the table.
+func (t *Table) ForeignKeys() []*Constraint {
+	var result []*Constraint
+	for _, c := range t.Constraints {
+		if c.ConstraintType == ForeignKey {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// IsView reports whether the table is a view.
+func (t *Table) IsView() bool {
+	return t.TableType == "VIEW"
+}
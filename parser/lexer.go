@@ -0,0 +1,395 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF    tokenKind = iota
+	tokWord             // keyword or unquoted identifier
+	tokIdent            // quoted identifier, text is unquoted
+	tokString           // string literal, text is unquoted
+	tokNumber           // numeric literal
+	tokPunct            // punctuation and operators
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // offset of the token in the source
+	end  int
+	line int
+}
+
+// lex splits src into tokens, comments are dropped.
+func lex(src string, d dialect) ([]token, error) {
+	var result []token
+	line := 1
+	for i := 0; i < len(src); {
+		ch := src[i]
+		switch {
+		case ch == '\n':
+			line++
+			i++
+			continue
+		case ch == ' ' || ch == '\t' || ch == '\r' || ch == '\f':
+			i++
+			continue
+		case strings.HasPrefix(src[i:], "--"), ch == '#' && d.hashComment():
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+			continue
+		}
+
+		t := token{pos: i, line: line}
+		switch {
+		case ch == '\'' || ch == '"' && !d.quotedIdentifier('"'):
+			text, end, err := lexQuoted(src, i, ch, d.backslashEscape())
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			t.kind, t.text, i = tokString, text, end
+		case d.quotedIdentifier(ch):
+			text, end, err := lexQuoted(src, i, ch, false)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			t.kind, t.text, i = tokIdent, text, end
+		case ch == '$' && d.dollarQuote():
+			// $tag$ ... $tag$, used by function bodies
+			tagEnd := strings.IndexByte(src[i+1:], '$')
+			if tagEnd < 0 {
+				return nil, fmt.Errorf("line %d: unterminated dollar quote", line)
+			}
+			tag := src[i : i+tagEnd+2]
+			end := strings.Index(src[i+len(tag):], tag)
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated dollar quote", line)
+			}
+			t.kind, t.text = tokString, src[i+len(tag):i+len(tag)+end]
+			i += len(tag) + end + len(tag)
+		case isDigit(ch) || ch == '.' && i+1 < len(src) && isDigit(src[i+1]):
+			for i < len(src) && (isDigit(src[i]) || src[i] == '.' || src[i] == 'e' || src[i] == 'E') {
+				i++
+			}
+			t.kind, t.text = tokNumber, src[t.pos:i]
+		case isWordChar(ch):
+			for i < len(src) && (isWordChar(src[i]) || isDigit(src[i]) || src[i] == '$') {
+				i++
+			}
+			t.kind, t.text = tokWord, src[t.pos:i]
+		case strings.HasPrefix(src[i:], "::"):
+			t.kind, t.text = tokPunct, "::"
+			i += 2
+		default:
+			t.kind, t.text = tokPunct, string(ch)
+			i++
+		}
+		t.end = i
+		line += strings.Count(src[t.pos:t.end], "\n")
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+// lexQuoted scans the quoted text starting at src[start], a doubled quote
+// escapes the quote.
+func lexQuoted(src string, start int, quote byte, backslash bool) (string, int, error) {
+	var b strings.Builder
+	for i := start + 1; i < len(src); i++ {
+		ch := src[i]
+		switch {
+		case backslash && ch == '\\' && i+1 < len(src):
+			i++
+			b.WriteByte(unescape(src[i]))
+		case ch == quote && i+1 < len(src) && src[i+1] == quote:
+			i++
+			b.WriteByte(quote)
+		case ch == quote:
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated quoted text %v", src[start:min(start+20, len(src))])
+}
+
+func unescape(ch byte) byte {
+	switch ch {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	case 'r':
+		return '\r'
+	case '0':
+		return 0
+	}
+	return ch
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isWordChar(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch == '_' || ch >= 0x80
+}
+
+// splitStatements splits tokens by semicolons.
+func splitStatements(tokens []token) [][]token {
+	var result [][]token
+	start := 0
+	for i, t := range tokens {
+		if t.kind == tokPunct && t.text == ";" {
+			if i > start {
+				result = append(result, tokens[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if start < len(tokens) {
+		result = append(result, tokens[start:])
+	}
+	return result
+}
+
+// cursor walks through the tokens of a statement.
+type cursor struct {
+	src    string
+	tokens []token
+	i      int
+	d      dialect
+}
+
+func (c *cursor) peek() token {
+	return c.peekAt(0)
+}
+
+func (c *cursor) peekAt(n int) token {
+	if c.i+n < len(c.tokens) {
+		return c.tokens[c.i+n]
+	}
+	t := token{kind: tokEOF}
+	if len(c.tokens) > 0 {
+		last := c.tokens[len(c.tokens)-1]
+		t.pos, t.end, t.line = last.end, last.end, last.line
+	}
+	return t
+}
+
+func (c *cursor) next() token {
+	t := c.peek()
+	if c.i < len(c.tokens) {
+		c.i++
+	}
+	return t
+}
+
+func (c *cursor) eof() bool {
+	return c.i >= len(c.tokens)
+}
+
+// is reports whether the next tokens are the keywords.
+func (c *cursor) is(words ...string) bool {
+	for n, w := range words {
+		t := c.peekAt(n)
+		if t.kind != tokWord || !strings.EqualFold(t.text, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// accept consumes the keywords if the next tokens are them.
+func (c *cursor) accept(words ...string) bool {
+	if c.is(words...) {
+		c.i += len(words)
+		return true
+	}
+	return false
+}
+
+func (c *cursor) expect(words ...string) error {
+	if !c.accept(words...) {
+		return c.unexpected(strings.Join(words, " "))
+	}
+	return nil
+}
+
+func (c *cursor) isPunct(p string) bool {
+	t := c.peek()
+	return t.kind == tokPunct && t.text == p
+}
+
+func (c *cursor) acceptPunct(p string) bool {
+	if c.isPunct(p) {
+		c.i++
+		return true
+	}
+	return false
+}
+
+func (c *cursor) expectPunct(p string) error {
+	if !c.acceptPunct(p) {
+		return c.unexpected(p)
+	}
+	return nil
+}
+
+func (c *cursor) unexpected(expected string) error {
+	t := c.peek()
+	if t.kind == tokEOF {
+		return fmt.Errorf("line %d: expected %v, got end of statement", t.line, expected)
+	}
+	return fmt.Errorf("line %d: expected %v, got %q", t.line, expected, c.src[t.pos:t.end])
+}
+
+// ident consumes an identifier, unquoted identifiers are folded by the dialect.
+func (c *cursor) ident() (string, error) {
+	t := c.peek()
+	switch t.kind {
+	case tokIdent:
+		c.i++
+		return t.text, nil
+	case tokWord:
+		c.i++
+		return c.d.fold(t.text), nil
+	case tokString:
+		// mysql accepts string literals as some names, e.g. of indexes
+		if !c.d.quotedIdentifier('"') {
+			c.i++
+			return t.text, nil
+		}
+	}
+	return "", c.unexpected("identifier")
+}
+
+// name returns the identifier of a word or quoted identifier token.
+func (c *cursor) name(t token) string {
+	if t.kind == tokWord {
+		return c.d.fold(t.text)
+	}
+	return t.text
+}
+
+// qualifiedName consumes a name optionally qualified by schema.
+func (c *cursor) qualifiedName() (schema, name string, err error) {
+	if name, err = c.ident(); err != nil {
+		return "", "", err
+	}
+	if c.acceptPunct(".") {
+		schema = name
+		if name, err = c.ident(); err != nil {
+			return "", "", err
+		}
+	}
+	return schema, name, nil
+}
+
+// identList consumes a parenthesized list of identifiers.
+func (c *cursor) identList() ([]string, error) {
+	if err := c.expectPunct("("); err != nil {
+		return nil, err
+	}
+	var result []string
+	for {
+		name, err := c.ident()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, name)
+		if c.acceptPunct(")") {
+			return result, nil
+		}
+		if err := c.expectPunct(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// skipGroup consumes the parenthesized tokens if the next token is '('.
+func (c *cursor) skipGroup() {
+	if !c.isPunct("(") {
+		return
+	}
+	depth := 0
+	for !c.eof() {
+		t := c.next()
+		if t.kind != tokPunct {
+			continue
+		}
+		switch t.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+// skipElement consumes tokens up to the next ',' or the closing ')' of the
+// enclosing list, which are not consumed.
+func (c *cursor) skipElement() {
+	c.until(func() bool { return false })
+}
+
+// until consumes tokens until stop reports true, or a ',' or unbalanced ')'
+// is found at the top level. It returns the consumed tokens.
+func (c *cursor) until(stop func() bool) []token {
+	start := c.i
+	depth := 0
+	for !c.eof() {
+		t := c.peek()
+		if t.kind == tokPunct {
+			switch t.text {
+			case "(":
+				depth++
+			case ")":
+				if depth == 0 {
+					return c.tokens[start:c.i]
+				}
+				depth--
+			case ",":
+				if depth == 0 {
+					return c.tokens[start:c.i]
+				}
+			}
+		}
+		if depth == 0 && c.i > start && stop() {
+			break
+		}
+		c.i++
+	}
+	return c.tokens[start:c.i]
+}
+
+// text returns the source text of the tokens.
+func (c *cursor) text(tokens []token) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+	return c.src[tokens[0].pos:tokens[len(tokens)-1].end]
+}
@@ -0,0 +1,203 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+type mysqlDialect struct {
+}
+
+func (mysqlDialect) fold(name string) string {
+	return name
+}
+
+func (mysqlDialect) quotedIdentifier(ch byte) bool {
+	return ch == '`'
+}
+
+func (mysqlDialect) hashComment() bool {
+	return true
+}
+
+func (mysqlDialect) backslashEscape() bool {
+	return true
+}
+
+func (mysqlDialect) dollarQuote() bool {
+	return false
+}
+
+// mysqlTypes maps type aliases to the type mysql reports
+var mysqlTypes = map[string]string{
+	"INTEGER":           "int",
+	"BOOL":              "tinyint",
+	"BOOLEAN":           "tinyint",
+	"DEC":               "decimal",
+	"NUMERIC":           "decimal",
+	"FIXED":             "decimal",
+	"REAL":              "double",
+	"DOUBLE PRECISION":  "double",
+	"CHARACTER":         "char",
+	"NCHAR":             "char",
+	"CHARACTER VARYING": "varchar",
+	"NVARCHAR":          "varchar",
+}
+
+func (mysqlDialect) columnType(words []string, args string, array bool) (string, string, bool) {
+	var name, modifiers []string
+	for _, w := range words {
+		switch w {
+		case "UNSIGNED", "ZEROFILL":
+			modifiers = append(modifiers, strings.ToLower(w))
+		case "SIGNED":
+		default:
+			name = append(name, w)
+		}
+	}
+	dataType := strings.ToLower(strings.Join(name, " "))
+	if alias, ok := mysqlTypes[strings.Join(name, " ")]; ok {
+		dataType = alias
+	}
+	switch {
+	case args == "" && (name[0] == "BOOL" || name[0] == "BOOLEAN"):
+		args = "1"
+	case args == "" && dataType == "decimal":
+		args = "10,0"
+	}
+
+	columnType := dataType
+	if args != "" {
+		columnType += "(" + args + ")"
+	}
+	if len(modifiers) > 0 {
+		columnType += " " + strings.Join(modifiers, " ")
+	}
+	return dataType, columnType, false
+}
+
+// mysqlCurrentTimestamp are the synonyms of CURRENT_TIMESTAMP
+var mysqlCurrentTimestamp = map[string]bool{
+	"CURRENT_TIMESTAMP": true,
+	"NOW":               true,
+	"LOCALTIME":         true,
+	"LOCALTIMESTAMP":    true,
+}
+
+// defaultValue returns the default as COLUMN_DEFAULT reports it, string
+// literals are unquoted and expressions are marked DEFAULT_GENERATED.
+func (mysqlDialect) defaultValue(c *cursor, tokens []token) (string, bool, bool) {
+	if len(tokens) == 0 {
+		return "", true, false
+	}
+	first := tokens[0]
+	if len(tokens) == 1 {
+		switch {
+		case first.kind == tokString:
+			return first.text, false, false
+		case first.kind == tokWord && strings.EqualFold(first.text, "NULL"):
+			return "", true, false
+		case first.kind == tokWord && strings.EqualFold(first.text, "TRUE"):
+			return "1", false, false
+		case first.kind == tokWord && strings.EqualFold(first.text, "FALSE"):
+			return "0", false, false
+		}
+	}
+	if first.kind == tokWord && mysqlCurrentTimestamp[strings.ToUpper(first.text)] {
+		// CURRENT_TIMESTAMP, NOW() or CURRENT_TIMESTAMP(3)
+		if len(tokens) == 4 && tokens[2].kind == tokNumber {
+			return "CURRENT_TIMESTAMP(" + tokens[2].text + ")", false, true
+		}
+		return "CURRENT_TIMESTAMP", false, true
+	}
+	text := c.text(tokens)
+	if strings.HasPrefix(text, "(") && strings.HasSuffix(text, ")") && balanced(text[1:len(text)-1]) {
+		return text[1 : len(text)-1], false, true
+	}
+	return text, false, false
+}
+
+func (mysqlDialect) nullable(nullable bool) string {
+	if nullable {
+		return "YES"
+	}
+	return "NO"
+}
+
+// constraintName names the primary key PRIMARY, unique keys by their first
+// column and foreign keys <table>_ibfk_<n>.
+func (mysqlDialect) constraintName(t *model.Table, c *model.Constraint) string {
+	switch {
+	case c.ConstraintType == model.PrimaryKey:
+		return "PRIMARY"
+	case c.ConstraintName != "":
+		return c.ConstraintName
+	case c.ConstraintType == model.ForeignKey:
+		n := len(t.ForeignKeys()) + 1
+		for t.Constraint(fmt.Sprintf("%v_ibfk_%d", t.TableName, n)) != nil {
+			n++
+		}
+		return fmt.Sprintf("%v_ibfk_%d", t.TableName, n)
+	}
+	return uniqueName(t, c.Columns[0], func(n int) string { return fmt.Sprintf("_%d", n) })
+}
+
+// indexName names an index by its first column.
+func (mysqlDialect) indexName(t *model.Table, idx *model.Index) string {
+	base := idx.Columns[0].ColumnName
+	if base == "" {
+		base = "functional_index"
+	}
+	return uniqueName(t, base, func(n int) string { return fmt.Sprintf("_%d", n) })
+}
+
+// finish adds the indexes mysql creates for foreign keys and sets COLUMN_KEY.
+func (mysqlDialect) finish(t *model.Table) {
+	for _, fk := range t.ForeignKeys() {
+		if !hasLeadingColumns(t, fk.Columns) {
+			idx := &model.Index{IndexName: fk.ConstraintName, TableSchema: t.TableSchema, TableName: t.TableName,
+				IndexType: "BTREE", Visible: true}
+			for _, column := range fk.Columns {
+				idx.Columns = append(idx.Columns, &model.IndexColumn{ColumnName: column, Order: "ASC"})
+			}
+			t.Indexes = append(t.Indexes, idx)
+		}
+	}
+
+	keys := make(map[string]string)
+	for _, idx := range t.Indexes {
+		for i, column := range idx.Columns {
+			switch {
+			case idx.Primary:
+				keys[column.ColumnName] = "PRI"
+			case i > 0 || keys[column.ColumnName] == "PRI":
+			case idx.Unique && len(idx.Columns) == 1:
+				keys[column.ColumnName] = "UNI"
+			case keys[column.ColumnName] == "":
+				keys[column.ColumnName] = "MUL"
+			}
+		}
+	}
+	for _, c := range t.Columns {
+		c.ColumnKey = keys[c.ColumnName]
+	}
+}
+
+// hasLeadingColumns reports whether an index of t starts with the columns.
+func hasLeadingColumns(t *model.Table, columns []string) bool {
+	for _, idx := range t.Indexes {
+		if len(idx.Columns) < len(columns) {
+			continue
+		}
+		match := true
+		for i, column := range columns {
+			match = match && idx.Columns[i].ColumnName == column
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
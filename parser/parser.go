@@ -0,0 +1,1296 @@
+// Package parser parses DDL statements into the schema model, so that the
+// schema can be loaded from migration files without a database.
+//
+// CREATE TABLE, ALTER TABLE, CREATE INDEX, DROP, COMMENT ON and RENAME TABLE
+// statements are applied in order, other statements are ignored.
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+// Error define
+var (
+	ErrDialectNotFound = errors.New("dialect not found")
+)
+
+var (
+	dialects = map[string]func() dialect{
+		"mysql": func() dialect { return mysqlDialect{} },
+		"pgsql": func() dialect { return pgsqlDialect{} },
+	}
+)
+
+// dialect holds the engine specific rules of parsing and of the values the
+// database reports in information_schema.
+type dialect interface {
+	// fold returns an unquoted identifier as the database stores it.
+	fold(name string) string
+	quotedIdentifier(ch byte) bool
+	hashComment() bool
+	backslashEscape() bool
+	dollarQuote() bool
+
+	// columnType returns the types of a column declared as words(args)[].
+	// serial reports a pgsql serial type, which defaults to a sequence.
+	columnType(words []string, args string, array bool) (dataType, columnType string, serial bool)
+	// defaultValue returns the default declared by the tokens, generated
+	// reports an expression default.
+	defaultValue(c *cursor, tokens []token) (value string, null, generated bool)
+	// nullable returns IS_NULLABLE of a column.
+	nullable(nullable bool) string
+	// constraintName returns the name the database assigns to constraint c,
+	// which is the declared name if any.
+	constraintName(t *model.Table, c *model.Constraint) string
+	// indexName returns the name the database assigns to an unnamed index.
+	indexName(t *model.Table, idx *model.Index) string
+	// finish completes the derived attributes of a parsed table.
+	finish(t *model.Table)
+}
+
+// AllDialect returns all name of supported dialect, sorted
+func AllDialect() []string {
+	names := make([]string, 0, len(dialects))
+	for n := range dialects {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parser parses DDL statements of a dialect, the statements of later Parse
+// calls apply to the tables of earlier ones.
+type Parser struct {
+	d      dialect
+	schema string // schema of unqualified names
+	tables map[string][]*model.Table
+}
+
+// NewParser creates a parser for the dialect, unqualified names belong to schema.
+func NewParser(dialectName, schema string) (*Parser, error) {
+	create := dialects[dialectName]
+	if create == nil {
+		return nil, fmt.Errorf("%v %w", dialectName, ErrDialectNotFound)
+	}
+	return &Parser{d: create(), schema: schema, tables: make(map[string][]*model.Table)}, nil
+}
+
+// Tables returns the parsed tables keyed by schema.
+func (p *Parser) Tables() map[string][]*model.Table {
+	result := make(map[string][]*model.Table)
+	for schema, tables := range p.tables {
+		if len(tables) == 0 {
+			continue
+		}
+		for _, t := range tables {
+			p.finish(t)
+		}
+		result[schema] = tables
+	}
+	return result
+}
+
+// finish fills the attributes derived from the table definition.
+func (p *Parser) finish(t *model.Table) {
+	for i, c := range t.Columns {
+		c.TableSchema, c.TableName = t.TableSchema, t.TableName
+		c.OrdinalPosition = uint32(i + 1)
+	}
+	for _, c := range t.Constraints {
+		c.TableSchema, c.TableName = t.TableSchema, t.TableName
+		if c.ConstraintType == model.ForeignKey && len(c.ReferencedColumns) == 0 {
+			// REFERENCES without columns references the primary key
+			if ref := p.table(c.ReferencedTableSchema, c.ReferencedTableName); ref != nil && ref.PrimaryKey() != nil {
+				c.ReferencedColumns = append([]string(nil), ref.PrimaryKey().Columns...)
+			}
+		}
+	}
+	for _, idx := range t.Indexes {
+		idx.TableSchema, idx.TableName = t.TableSchema, t.TableName
+	}
+	p.d.finish(t)
+}
+
+// Parse parses and applies the statements of src.
+func (p *Parser) Parse(src string) error {
+	tokens, err := lex(src, p.d)
+	if err != nil {
+		return err
+	}
+	for _, statement := range splitStatements(tokens) {
+		c := &cursor{src: src, tokens: statement, d: p.d}
+		if err := p.statement(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Parser) statement(c *cursor) error {
+	switch {
+	case c.accept("CREATE"):
+		return p.create(c)
+	case c.accept("ALTER", "TABLE"):
+		return p.alterTable(c)
+	case c.accept("ALTER", "INDEX"):
+		return p.alterIndex(c)
+	case c.accept("DROP", "TABLE"), c.accept("DROP", "VIEW"):
+		return p.dropTables(c)
+	case c.accept("DROP", "INDEX"):
+		return p.dropIndex(c)
+	case c.accept("COMMENT", "ON"):
+		return p.commentOn(c)
+	case c.accept("RENAME", "TABLE"):
+		return p.renameTables(c)
+	case c.accept("USE"):
+		schema, err := c.ident()
+		if err != nil {
+			return err
+		}
+		p.schema = schema
+	case c.accept("SET", "search_path"):
+		if !c.acceptPunct("=") {
+			c.accept("TO")
+		}
+		if schema, err := c.ident(); err == nil {
+			p.schema = schema
+		}
+	}
+	return nil
+}
+
+// create parses CREATE TABLE, CREATE INDEX and CREATE VIEW.
+func (p *Parser) create(c *cursor) error {
+	var modifiers []string
+	for !c.eof() {
+		switch {
+		case c.accept("TABLE"):
+			return p.createTable(c)
+		case c.accept("INDEX"):
+			return p.createIndex(c, modifiers)
+		case c.accept("VIEW"):
+			return p.createView(c)
+		case c.is("MATERIALIZED"), c.is("SCHEMA"), c.is("DATABASE"), c.is("SEQUENCE"), c.is("FUNCTION"),
+			c.is("PROCEDURE"), c.is("TRIGGER"), c.is("TYPE"), c.is("EXTENSION"), c.is("ON"), c.isPunct("("):
+			return nil
+		}
+		// OR REPLACE, TEMPORARY, UNIQUE, DEFINER=... and the like
+		modifiers = append(modifiers, strings.ToUpper(c.next().text))
+	}
+	return nil
+}
+
+func (p *Parser) createTable(c *cursor) error {
+	c.accept("IF", "NOT", "EXISTS")
+	schema, name, err := c.qualifiedName()
+	if err != nil {
+		return err
+	}
+	if !c.acceptPunct("(") {
+		// CREATE TABLE ... AS SELECT or LIKE, the columns are unknown
+		return nil
+	}
+
+	t := &model.Table{TableSchema: p.schemaOf(schema), TableName: name, TableType: "BASE TABLE"}
+	for {
+		if err := p.tableElement(c, t); err != nil {
+			return err
+		}
+		if c.acceptPunct(")") {
+			break
+		}
+		if err := c.expectPunct(","); err != nil {
+			return err
+		}
+	}
+	for !c.eof() {
+		if c.accept("COMMENT") {
+			c.acceptPunct("=")
+			comment := c.next()
+			t.TableComment = comment.text
+			continue
+		}
+		c.next()
+	}
+
+	p.dropTable(t.TableSchema, t.TableName)
+	p.addTable(t)
+	return nil
+}
+
+func (p *Parser) createView(c *cursor) error {
+	c.accept("IF", "NOT", "EXISTS")
+	schema, name, err := c.qualifiedName()
+	if err != nil {
+		return err
+	}
+	t := &model.Table{TableSchema: p.schemaOf(schema), TableName: name, TableType: "VIEW"}
+	p.dropTable(t.TableSchema, t.TableName)
+	p.addTable(t)
+	return nil
+}
+
+// tableElement parses a column or a constraint of CREATE TABLE.
+func (p *Parser) tableElement(c *cursor, t *model.Table) error {
+	switch {
+	case c.is("CONSTRAINT"), c.is("PRIMARY", "KEY"), c.is("UNIQUE"), c.is("FOREIGN", "KEY"),
+		c.is("KEY"), c.is("INDEX"), c.is("FULLTEXT"), c.is("SPATIAL"):
+		return p.constraint(c, t)
+	case c.is("CHECK"), c.is("EXCLUDE"), c.is("LIKE"), c.is("PERIOD"):
+		c.skipElement()
+		return nil
+	}
+	column, err := p.column(c, t)
+	if err != nil {
+		return err
+	}
+	t.Columns = append(t.Columns, column)
+	return nil
+}
+
+// column parses a column definition, constraints declared by the column are
+// added to t.
+func (p *Parser) column(c *cursor, t *model.Table) (*model.Column, error) {
+	name, err := c.ident()
+	if err != nil {
+		return nil, err
+	}
+	column := &model.Column{ColumnName: name, ColumnDefaultNull: true}
+	serial, err := p.columnType(c, column)
+	if err != nil {
+		return nil, err
+	}
+	if serial {
+		column.ColumnDefaultNull = false
+		column.ColumnDefault = fmt.Sprintf("nextval('%v_%v_seq'::regclass)", t.TableName, name)
+	}
+
+	nullable := !serial
+	var extras []string
+	var constraintName string
+	for !c.eof() && !c.isPunct(",") && !c.isPunct(")") && !c.is("FIRST") && !c.is("AFTER") {
+		switch {
+		case c.accept("NOT", "NULL"):
+			nullable = false
+		case c.accept("NULL"):
+			nullable = true
+		case c.accept("DEFAULT"):
+			value, null, generated := c.d.defaultValue(c, c.until(c.columnAttribute))
+			column.ColumnDefaultNull, column.ColumnDefault = null, value
+			if generated {
+				extras = append(extras, "DEFAULT_GENERATED")
+			}
+		case c.accept("AUTO_INCREMENT"), c.accept("AUTOINCREMENT"):
+			extras = append(extras, "auto_increment")
+		case c.accept("ON", "UPDATE"):
+			extras = append(extras, "on update "+c.text(c.until(c.columnAttribute)))
+		case c.accept("COMMENT"):
+			column.ColumnComment = c.next().text
+		case c.accept("CONSTRAINT"):
+			if constraintName, err = c.ident(); err != nil {
+				return nil, err
+			}
+			continue
+		case c.accept("PRIMARY", "KEY"), c.accept("KEY"):
+			nullable = false
+			p.addConstraint(t, &model.Constraint{ConstraintName: constraintName, ConstraintType: model.PrimaryKey,
+				Columns: []string{name}})
+		case c.accept("UNIQUE"):
+			c.accept("KEY")
+			p.addConstraint(t, &model.Constraint{ConstraintName: constraintName, ConstraintType: model.Unique,
+				Columns: []string{name}})
+		case c.accept("REFERENCES"):
+			fk := &model.Constraint{ConstraintName: constraintName, ConstraintType: model.ForeignKey,
+				Columns: []string{name}}
+			if err := p.references(c, fk); err != nil {
+				return nil, err
+			}
+			p.addConstraint(t, fk)
+		case c.accept("GENERATED", "ALWAYS", "AS", "IDENTITY"), c.accept("GENERATED", "BY", "DEFAULT", "AS", "IDENTITY"):
+			nullable = false
+			c.skipGroup()
+			extras = append(extras, "auto_increment")
+		case c.accept("GENERATED", "ALWAYS", "AS"), c.accept("AS"):
+			c.skipGroup()
+			kind := "VIRTUAL"
+			if c.accept("STORED") {
+				kind = "STORED"
+			}
+			c.accept("VIRTUAL")
+			extras = append(extras, kind+" GENERATED")
+		case c.accept("INVISIBLE"):
+			extras = append(extras, "INVISIBLE")
+		case c.accept("CHARACTER", "SET"), c.accept("CHARSET"), c.accept("COLLATE"),
+			c.accept("COLUMN_FORMAT"), c.accept("STORAGE"), c.accept("SRID"):
+			c.next()
+		case c.accept("CHECK"):
+			c.skipGroup()
+		default:
+			c.next()
+		}
+		constraintName = ""
+	}
+
+	column.IsNullable = c.d.nullable(nullable)
+	column.Extra = strings.Join(extras, " ")
+	return column, nil
+}
+
+// columnAttribute reports whether the next token starts a column attribute,
+// which ends the expression of the previous attribute.
+func (c *cursor) columnAttribute() bool {
+	for _, words := range [][]string{
+		{"NOT", "NULL"}, {"NULL"}, {"DEFAULT"}, {"AUTO_INCREMENT"}, {"AUTOINCREMENT"}, {"ON", "UPDATE"},
+		{"COMMENT"}, {"CONSTRAINT"}, {"PRIMARY"}, {"KEY"}, {"UNIQUE"}, {"REFERENCES"}, {"GENERATED"},
+		{"INVISIBLE"}, {"VISIBLE"}, {"COLLATE"}, {"CHECK"}, {"STORED"}, {"VIRTUAL"},
+	} {
+		if c.is(words...) {
+			return true
+		}
+	}
+	return false
+}
+
+// typeWords are the keywords continuing a type name.
+var typeWords = map[string]bool{
+	"VARYING":   true,
+	"PRECISION": true,
+	"UNSIGNED":  true,
+	"SIGNED":    true,
+	"ZEROFILL":  true,
+}
+
+// columnType parses the type of column.
+func (p *Parser) columnType(c *cursor, column *model.Column) (serial bool, err error) {
+	first := c.next()
+	if first.kind != tokWord && first.kind != tokIdent {
+		c.i--
+		return false, c.unexpected("column type")
+	}
+	words := []string{strings.ToUpper(first.text)}
+	var args []string
+	array := false
+	for !c.eof() {
+		switch {
+		case c.peek().kind == tokWord && typeWords[strings.ToUpper(c.peek().text)]:
+			words = append(words, strings.ToUpper(c.next().text))
+		case c.is("WITH", "TIME", "ZONE"), c.is("WITHOUT", "TIME", "ZONE"):
+			for i := 0; i < 3; i++ {
+				words = append(words, strings.ToUpper(c.next().text))
+			}
+		case c.isPunct("(") && args == nil:
+			c.next()
+			for !c.acceptPunct(")") {
+				if c.eof() {
+					return false, c.unexpected(")")
+				}
+				t := c.next()
+				if t.kind == tokString {
+					args = append(args, "'"+strings.ReplaceAll(t.text, "'", "''")+"'")
+				} else {
+					args = append(args, t.text)
+				}
+			}
+			if args == nil {
+				args = []string{}
+			}
+		case c.acceptPunct("["):
+			for !c.eof() && !c.acceptPunct("]") {
+				c.next()
+			}
+			array = true
+		default:
+			column.DataType, column.ColumnType, serial = p.d.columnType(words, strings.Join(args, ""), array)
+			return serial, nil
+		}
+	}
+	column.DataType, column.ColumnType, serial = p.d.columnType(words, strings.Join(args, ""), array)
+	return serial, nil
+}
+
+// constraint parses a table constraint or index of CREATE TABLE or ALTER TABLE ADD.
+func (p *Parser) constraint(c *cursor, t *model.Table) error {
+	var name string
+	var err error
+	if c.accept("CONSTRAINT") {
+		if !c.is("PRIMARY") && !c.is("UNIQUE") && !c.is("FOREIGN") && !c.is("CHECK") {
+			if name, err = c.ident(); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch {
+	case c.accept("PRIMARY", "KEY"):
+		cons := &model.Constraint{ConstraintName: name, ConstraintType: model.PrimaryKey}
+		idx, err := p.indexDefinition(c, "")
+		if err != nil {
+			return err
+		}
+		for _, column := range idx.Columns {
+			cons.Columns = append(cons.Columns, column.ColumnName)
+			if col := t.Column(column.ColumnName); col != nil {
+				col.IsNullable = c.d.nullable(false)
+			}
+		}
+		p.addConstraint(t, cons)
+		p.indexOf(t, cons).Columns = idx.Columns
+	case c.accept("UNIQUE"):
+		if !c.accept("KEY") {
+			c.accept("INDEX")
+		}
+		idx, err := p.indexDefinition(c, "")
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			name = idx.IndexName
+		}
+		cons := &model.Constraint{ConstraintName: name, ConstraintType: model.Unique}
+		for _, column := range idx.Columns {
+			cons.Columns = append(cons.Columns, column.ColumnName)
+		}
+		p.addConstraint(t, cons)
+		index := p.indexOf(t, cons)
+		index.Columns, index.IndexType, index.Comment, index.Visible = idx.Columns, idx.IndexType, idx.Comment, idx.Visible
+	case c.accept("FOREIGN", "KEY"):
+		if !c.isPunct("(") {
+			// mysql names the index of the foreign key
+			if _, err := c.ident(); err != nil {
+				return err
+			}
+		}
+		fk := &model.Constraint{ConstraintName: name, ConstraintType: model.ForeignKey}
+		if fk.Columns, err = c.identList(); err != nil {
+			return err
+		}
+		if err := c.expect("REFERENCES"); err != nil {
+			return err
+		}
+		if err := p.references(c, fk); err != nil {
+			return err
+		}
+		p.addConstraint(t, fk)
+	case c.accept("FULLTEXT"), c.accept("SPATIAL"):
+		indexType := strings.ToUpper(c.peekAt(-1).text)
+		if !c.accept("KEY") {
+			c.accept("INDEX")
+		}
+		idx, err := p.indexDefinition(c, indexType)
+		if err != nil {
+			return err
+		}
+		p.addIndex(t, idx)
+	case c.accept("KEY"), c.accept("INDEX"):
+		idx, err := p.indexDefinition(c, "")
+		if err != nil {
+			return err
+		}
+		p.addIndex(t, idx)
+	default:
+		// CHECK and others
+		c.skipElement()
+	}
+	return nil
+}
+
+// indexDefinition parses [name] [USING type] (key parts) [options] of a
+// mysql index or constraint.
+func (p *Parser) indexDefinition(c *cursor, indexType string) (*model.Index, error) {
+	idx := &model.Index{IndexType: indexType, Visible: true}
+	if !c.isPunct("(") && !c.is("USING") {
+		name, err := c.ident()
+		if err != nil {
+			return nil, err
+		}
+		idx.IndexName = name
+	}
+	if err := p.indexOptions(c, idx); err != nil {
+		return nil, err
+	}
+	if err := p.keyParts(c, idx); err != nil {
+		return nil, err
+	}
+	if err := p.indexOptions(c, idx); err != nil {
+		return nil, err
+	}
+	if idx.IndexType == "" {
+		idx.IndexType = "BTREE"
+	}
+	return idx, nil
+}
+
+// indexOptions parses the options of an index up to the end of the element.
+func (p *Parser) indexOptions(c *cursor, idx *model.Index) error {
+	for !c.eof() && !c.isPunct(",") && !c.isPunct(")") && !c.isPunct("(") {
+		switch {
+		case c.accept("USING", "INDEX", "TABLESPACE"):
+			c.next()
+		case c.accept("USING"):
+			idx.IndexType = strings.ToUpper(c.next().text)
+		case c.accept("COMMENT"):
+			idx.Comment = c.next().text
+		case c.accept("INVISIBLE"):
+			idx.Visible = false
+		case c.accept("VISIBLE"):
+			idx.Visible = true
+		case c.accept("WHERE"):
+			idx.Predicate = c.text(c.until(func() bool { return false }))
+		case c.accept("INCLUDE"), c.accept("WITH"):
+			c.skipGroup()
+		default:
+			c.next()
+		}
+	}
+	return nil
+}
+
+// keyParts parses the parenthesized key parts of an index.
+func (p *Parser) keyParts(c *cursor, idx *model.Index) error {
+	if err := c.expectPunct("("); err != nil {
+		return err
+	}
+	for {
+		part := c.until(func() bool { return false })
+		if len(part) == 0 {
+			return c.unexpected("key part")
+		}
+		idx.Columns = append(idx.Columns, p.keyPart(c, part))
+		if c.acceptPunct(")") {
+			return nil
+		}
+		if err := c.expectPunct(","); err != nil {
+			return err
+		}
+	}
+}
+
+// keyPart parses a column or an expression of an index.
+func (p *Parser) keyPart(c *cursor, part []token) *model.IndexColumn {
+	column := &model.IndexColumn{Order: "ASC"}
+	last := func(words ...string) bool {
+		if len(part) < len(words) {
+			return false
+		}
+		for i, w := range words {
+			t := part[len(part)-len(words)+i]
+			if t.kind != tokWord || !strings.EqualFold(t.text, w) {
+				return false
+			}
+		}
+		part = part[:len(part)-len(words)]
+		return true
+	}
+	for {
+		if last("NULLS", "FIRST") || last("NULLS", "LAST") || last("ASC") {
+			continue
+		}
+		if last("DESC") {
+			column.Order = "DESC"
+			continue
+		}
+		break
+	}
+
+	first := part[0]
+	isName := first.kind == tokWord || first.kind == tokIdent
+	switch {
+	case isName && len(part) == 1:
+		column.ColumnName = c.name(first)
+	case isName && len(part) == 2 && part[1].kind == tokWord:
+		// column followed by an operator class
+		column.ColumnName = c.name(first)
+	case isName && len(part) == 4 && part[1].text == "(" && part[2].kind == tokNumber && part[3].text == ")":
+		column.ColumnName = c.name(first)
+		fmt.Sscan(part[2].text, &column.SubPart)
+	default:
+		expr := c.text(part)
+		if strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")") && balanced(expr[1:len(expr)-1]) {
+			expr = expr[1 : len(expr)-1]
+		}
+		column.Expression = expr
+	}
+	return column
+}
+
+// balanced reports whether the parentheses of s are balanced, ignoring quotes.
+func balanced(s string) bool {
+	depth := 0
+	for _, ch := range s {
+		switch ch {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+// references parses the referenced table and the actions of a foreign key.
+func (p *Parser) references(c *cursor, fk *model.Constraint) error {
+	schema, name, err := c.qualifiedName()
+	if err != nil {
+		return err
+	}
+	fk.ReferencedTableSchema, fk.ReferencedTableName = p.schemaOf(schema), name
+	if c.isPunct("(") {
+		if fk.ReferencedColumns, err = c.identList(); err != nil {
+			return err
+		}
+	}
+	fk.UpdateRule, fk.DeleteRule, fk.MatchOption = "NO ACTION", "NO ACTION", "NONE"
+	for !c.eof() && !c.isPunct(",") && !c.isPunct(")") {
+		switch {
+		case c.accept("MATCH"):
+			fk.MatchOption = strings.ToUpper(c.next().text)
+			if fk.MatchOption == "SIMPLE" {
+				fk.MatchOption = "NONE"
+			}
+		case c.accept("ON", "UPDATE"):
+			fk.UpdateRule = referentialAction(c)
+		case c.accept("ON", "DELETE"):
+			fk.DeleteRule = referentialAction(c)
+		case c.columnAttribute():
+			return nil
+		default:
+			// DEFERRABLE, INITIALLY DEFERRED and the like
+			c.next()
+		}
+	}
+	return nil
+}
+
+func referentialAction(c *cursor) string {
+	for _, action := range []string{"CASCADE", "SET NULL", "SET DEFAULT", "RESTRICT", "NO ACTION"} {
+		if c.accept(strings.Fields(action)...) {
+			return action
+		}
+	}
+	return strings.ToUpper(c.next().text)
+}
+
+func (p *Parser) createIndex(c *cursor, modifiers []string) error {
+	c.accept("CONCURRENTLY")
+	c.accept("IF", "NOT", "EXISTS")
+	idx := &model.Index{IndexType: "BTREE", Visible: true}
+	for _, m := range modifiers {
+		switch m {
+		case "UNIQUE":
+			idx.Unique = true
+		case "FULLTEXT", "SPATIAL":
+			idx.IndexType = m
+		}
+	}
+
+	var indexSchema string
+	if !c.is("ON") {
+		var err error
+		if indexSchema, idx.IndexName, err = c.qualifiedName(); err != nil {
+			return err
+		}
+	}
+	if err := c.expect("ON"); err != nil {
+		return err
+	}
+	c.accept("ONLY")
+	schema, name, err := c.qualifiedName()
+	if err != nil {
+		return err
+	}
+	if schema == "" {
+		schema = indexSchema
+	}
+	if err := p.indexOptions(c, idx); err != nil {
+		return err
+	}
+	if err := p.keyParts(c, idx); err != nil {
+		return err
+	}
+	if err := p.indexOptions(c, idx); err != nil {
+		return err
+	}
+
+	t := p.table(p.schemaOf(schema), name)
+	if t == nil {
+		return fmt.Errorf("line %d: create index on unknown table %v", c.tokens[0].line, name)
+	}
+	p.addIndex(t, idx)
+	return nil
+}
+
+func (p *Parser) alterTable(c *cursor) error {
+	c.accept("IF", "EXISTS")
+	c.accept("ONLY")
+	schema, name, err := c.qualifiedName()
+	if err != nil {
+		return err
+	}
+	t := p.table(p.schemaOf(schema), name)
+	if t == nil {
+		return fmt.Errorf("line %d: alter unknown table %v", c.tokens[0].line, name)
+	}
+	for !c.eof() {
+		if err := p.alterAction(c, t); err != nil {
+			return err
+		}
+		// skip what is left of unsupported actions
+		c.skipElement()
+		if !c.acceptPunct(",") && !c.eof() {
+			return c.unexpected(",")
+		}
+	}
+	return nil
+}
+
+func (p *Parser) alterAction(c *cursor, t *model.Table) error {
+	switch {
+	case c.accept("ADD"):
+		switch {
+		case c.is("CONSTRAINT"), c.is("PRIMARY", "KEY"), c.is("UNIQUE"), c.is("FOREIGN", "KEY"),
+			c.is("KEY"), c.is("INDEX"), c.is("FULLTEXT"), c.is("SPATIAL"):
+			return p.constraint(c, t)
+		case c.is("CHECK"):
+			return nil
+		}
+		c.accept("COLUMN")
+		c.accept("IF", "NOT", "EXISTS")
+		column, err := p.column(c, t)
+		if err != nil {
+			return err
+		}
+		if t.Column(column.ColumnName) == nil {
+			t.Columns = append(t.Columns, column)
+			return placeColumn(c, t, column)
+		}
+	case c.accept("DROP"):
+		return p.alterDrop(c, t)
+	case c.accept("MODIFY"):
+		c.accept("COLUMN")
+		column, err := p.column(c, t)
+		if err != nil {
+			return err
+		}
+		p.replaceColumn(t, column.ColumnName, column)
+		return placeColumn(c, t, column)
+	case c.accept("CHANGE"):
+		c.accept("COLUMN")
+		old, err := c.ident()
+		if err != nil {
+			return err
+		}
+		column, err := p.column(c, t)
+		if err != nil {
+			return err
+		}
+		p.replaceColumn(t, old, column)
+		return placeColumn(c, t, column)
+	case c.accept("ALTER", "INDEX"):
+		name, err := c.ident()
+		if err != nil {
+			return err
+		}
+		if idx := indexNamed(t, name); idx != nil {
+			idx.Visible = !c.accept("INVISIBLE")
+		}
+	case c.accept("ALTER"):
+		c.accept("COLUMN")
+		name, err := c.ident()
+		if err != nil {
+			return err
+		}
+		column := t.Column(name)
+		if column == nil {
+			return fmt.Errorf("line %d: alter unknown column %v.%v", c.tokens[0].line, t.TableName, name)
+		}
+		return p.alterColumn(c, t, column)
+	case c.accept("RENAME"):
+		return p.alterRename(c, t)
+	case c.accept("COMMENT"):
+		c.acceptPunct("=")
+		t.TableComment = c.next().text
+	}
+	return nil
+}
+
+func (p *Parser) alterDrop(c *cursor, t *model.Table) error {
+	switch {
+	case c.accept("PRIMARY", "KEY"):
+		if pk := t.PrimaryKey(); pk != nil {
+			p.dropConstraint(t, pk.ConstraintName)
+		}
+		return nil
+	case c.accept("FOREIGN", "KEY"), c.accept("CONSTRAINT"), c.accept("INDEX"), c.accept("KEY"), c.accept("CHECK"):
+		c.accept("IF", "EXISTS")
+		name, err := c.ident()
+		if err != nil {
+			return err
+		}
+		p.dropConstraint(t, name)
+		return nil
+	}
+	c.accept("COLUMN")
+	c.accept("IF", "EXISTS")
+	name, err := c.ident()
+	if err != nil {
+		return err
+	}
+	p.dropColumn(t, name)
+	return nil
+}
+
+func (p *Parser) alterColumn(c *cursor, t *model.Table, column *model.Column) error {
+	switch {
+	case c.accept("SET", "DEFAULT"):
+		value, null, generated := c.d.defaultValue(c, c.until(func() bool { return false }))
+		column.ColumnDefaultNull, column.ColumnDefault = null, value
+		if generated && !strings.Contains(column.Extra, "DEFAULT_GENERATED") {
+			column.Extra = strings.TrimSpace("DEFAULT_GENERATED " + column.Extra)
+		}
+	case c.accept("DROP", "DEFAULT"):
+		column.ColumnDefaultNull, column.ColumnDefault = true, ""
+		column.Extra = strings.TrimSpace(strings.Replace(column.Extra, "DEFAULT_GENERATED", "", 1))
+	case c.accept("SET", "NOT", "NULL"):
+		column.IsNullable = c.d.nullable(false)
+	case c.accept("DROP", "NOT", "NULL"):
+		column.IsNullable = c.d.nullable(true)
+	case c.accept("SET", "DATA", "TYPE"), c.accept("TYPE"):
+		if _, err := p.columnType(c, column); err != nil {
+			return err
+		}
+	case c.accept("SET", "VISIBLE"), c.accept("SET", "INVISIBLE"):
+		column.Extra = strings.TrimSpace(strings.Replace(column.Extra, "INVISIBLE", "", 1))
+		if strings.EqualFold(c.peekAt(-1).text, "INVISIBLE") {
+			column.Extra = strings.TrimSpace(column.Extra + " INVISIBLE")
+		}
+	case c.accept("ADD", "GENERATED"):
+		column.Extra = "auto_increment"
+		column.IsNullable = c.d.nullable(false)
+	case c.accept("DROP", "IDENTITY"):
+		column.Extra = ""
+	}
+	return nil
+}
+
+func (p *Parser) alterRename(c *cursor, t *model.Table) error {
+	switch {
+	case c.accept("COLUMN"), c.is("CONSTRAINT"), c.is("INDEX"), c.is("KEY"),
+		!c.is("TO") && !c.is("AS") && c.peekAt(1).kind == tokWord && strings.EqualFold(c.peekAt(1).text, "TO"):
+		kind := ""
+		if c.accept("CONSTRAINT") || c.accept("INDEX") || c.accept("KEY") {
+			kind = "index"
+		}
+		from, err := c.ident()
+		if err != nil {
+			return err
+		}
+		if err := c.expect("TO"); err != nil {
+			return err
+		}
+		to, err := c.ident()
+		if err != nil {
+			return err
+		}
+		if kind == "index" {
+			renameIndex(t, from, to)
+		} else {
+			p.renameColumn(t, from, to)
+		}
+	default:
+		if !c.accept("TO") {
+			c.accept("AS")
+		}
+		_, name, err := c.qualifiedName()
+		if err != nil {
+			return err
+		}
+		p.renameTable(t, name)
+	}
+	return nil
+}
+
+func (p *Parser) alterIndex(c *cursor) error {
+	c.accept("IF", "EXISTS")
+	schema, name, err := c.qualifiedName()
+	if err != nil {
+		return err
+	}
+	if !c.accept("RENAME", "TO") {
+		return nil
+	}
+	to, err := c.ident()
+	if err != nil {
+		return err
+	}
+	for _, t := range p.tables[p.schemaOf(schema)] {
+		renameIndex(t, name, to)
+	}
+	return nil
+}
+
+func (p *Parser) dropTables(c *cursor) error {
+	c.accept("IF", "EXISTS")
+	for {
+		schema, name, err := c.qualifiedName()
+		if err != nil {
+			return err
+		}
+		p.dropTable(p.schemaOf(schema), name)
+		if !c.acceptPunct(",") {
+			return nil
+		}
+	}
+}
+
+func (p *Parser) dropIndex(c *cursor) error {
+	c.accept("CONCURRENTLY")
+	c.accept("IF", "EXISTS")
+	schema, name, err := c.qualifiedName()
+	if err != nil {
+		return err
+	}
+	if c.accept("ON") {
+		tableSchema, table, err := c.qualifiedName()
+		if err != nil {
+			return err
+		}
+		if t := p.table(p.schemaOf(tableSchema), table); t != nil {
+			p.dropConstraint(t, name)
+		}
+		return nil
+	}
+	for _, t := range p.tables[p.schemaOf(schema)] {
+		p.dropConstraint(t, name)
+	}
+	return nil
+}
+
+// commentOn parses COMMENT ON TABLE|COLUMN|INDEX name IS 'comment'.
+func (p *Parser) commentOn(c *cursor) error {
+	var kind string
+	switch {
+	case c.accept("TABLE"), c.accept("VIEW"):
+		kind = "table"
+	case c.accept("COLUMN"):
+		kind = "column"
+	case c.accept("INDEX"):
+		kind = "index"
+	default:
+		return nil
+	}
+
+	var names []string
+	for {
+		name, err := c.ident()
+		if err != nil {
+			return err
+		}
+		names = append(names, name)
+		if !c.acceptPunct(".") {
+			break
+		}
+	}
+	if err := c.expect("IS"); err != nil {
+		return err
+	}
+	comment := ""
+	if !c.accept("NULL") {
+		comment = c.next().text
+	}
+
+	schema := ""
+	if kind == "column" {
+		if len(names) < 2 {
+			return fmt.Errorf("line %d: comment on column without table", c.tokens[0].line)
+		}
+		if len(names) > 2 {
+			schema = names[len(names)-3]
+		}
+		t := p.table(p.schemaOf(schema), names[len(names)-2])
+		if t == nil {
+			return fmt.Errorf("line %d: comment on unknown table %v", c.tokens[0].line, names[len(names)-2])
+		}
+		column := t.Column(names[len(names)-1])
+		if column == nil {
+			return fmt.Errorf("line %d: comment on unknown column %v.%v", c.tokens[0].line, t.TableName,
+				names[len(names)-1])
+		}
+		column.ColumnComment = comment
+		return nil
+	}
+
+	if len(names) > 1 {
+		schema = names[len(names)-2]
+	}
+	name := names[len(names)-1]
+	if kind == "index" {
+		for _, t := range p.tables[p.schemaOf(schema)] {
+			if idx := indexNamed(t, name); idx != nil {
+				idx.Comment = comment
+			}
+		}
+		return nil
+	}
+	t := p.table(p.schemaOf(schema), name)
+	if t == nil {
+		return fmt.Errorf("line %d: comment on unknown table %v", c.tokens[0].line, name)
+	}
+	t.TableComment = comment
+	return nil
+}
+
+// renameTables parses the mysql RENAME TABLE a TO b[, c TO d].
+func (p *Parser) renameTables(c *cursor) error {
+	for {
+		schema, name, err := c.qualifiedName()
+		if err != nil {
+			return err
+		}
+		if err := c.expect("TO"); err != nil {
+			return err
+		}
+		_, to, err := c.qualifiedName()
+		if err != nil {
+			return err
+		}
+		if t := p.table(p.schemaOf(schema), name); t != nil {
+			p.renameTable(t, to)
+		}
+		if !c.acceptPunct(",") {
+			return nil
+		}
+	}
+}
+
+func (p *Parser) schemaOf(schema string) string {
+	if schema == "" {
+		return p.schema
+	}
+	return schema
+}
+
+func (p *Parser) table(schema, name string) *model.Table {
+	for _, t := range p.tables[schema] {
+		if t.TableName == name {
+			return t
+		}
+	}
+	return nil
+}
+
+func (p *Parser) addTable(t *model.Table) {
+	p.tables[t.TableSchema] = append(p.tables[t.TableSchema], t)
+}
+
+func (p *Parser) dropTable(schema, name string) {
+	tables := p.tables[schema]
+	for i, t := range tables {
+		if t.TableName == name {
+			p.tables[schema] = append(tables[:i:i], tables[i+1:]...)
+			return
+		}
+	}
+}
+
+func (p *Parser) renameTable(t *model.Table, name string) {
+	for _, tables := range p.tables {
+		for _, other := range tables {
+			for _, fk := range other.ForeignKeys() {
+				if fk.ReferencedTableSchema == t.TableSchema && fk.ReferencedTableName == t.TableName {
+					fk.ReferencedTableName = name
+				}
+			}
+		}
+	}
+	t.TableName = name
+}
+
+// addConstraint adds c to t, the index implementing a primary key or unique
+// constraint is added with it.
+func (p *Parser) addConstraint(t *model.Table, c *model.Constraint) {
+	c.TableSchema, c.TableName = t.TableSchema, t.TableName
+	c.ConstraintName = p.d.constraintName(t, c)
+	t.Constraints = append(t.Constraints, c)
+
+	if c.ConstraintType == model.PrimaryKey || c.ConstraintType == model.Unique {
+		idx := &model.Index{IndexName: c.ConstraintName, IndexType: "BTREE", Unique: true,
+			Primary: c.ConstraintType == model.PrimaryKey, Visible: true}
+		for _, column := range c.Columns {
+			idx.Columns = append(idx.Columns, &model.IndexColumn{ColumnName: column, Order: "ASC"})
+		}
+		t.Indexes = append(t.Indexes, idx)
+	}
+}
+
+// indexOf returns the index implementing c.
+func (p *Parser) indexOf(t *model.Table, c *model.Constraint) *model.Index {
+	return indexNamed(t, c.ConstraintName)
+}
+
+func (p *Parser) addIndex(t *model.Table, idx *model.Index) {
+	if idx.IndexName == "" {
+		idx.IndexName = p.d.indexName(t, idx)
+	}
+	t.Indexes = append(t.Indexes, idx)
+}
+
+func indexNamed(t *model.Table, name string) *model.Index {
+	for _, idx := range t.Indexes {
+		if idx.IndexName == name {
+			return idx
+		}
+	}
+	return nil
+}
+
+// dropConstraint drops the constraint or index with the name, a primary key
+// or unique constraint is dropped with its index.
+func (p *Parser) dropConstraint(t *model.Table, name string) {
+	var constraints []*model.Constraint
+	for _, c := range t.Constraints {
+		if c.ConstraintName != name {
+			constraints = append(constraints, c)
+		}
+	}
+	t.Constraints = constraints
+
+	var indexes []*model.Index
+	for _, idx := range t.Indexes {
+		if idx.IndexName != name {
+			indexes = append(indexes, idx)
+		}
+	}
+	t.Indexes = indexes
+}
+
+func renameIndex(t *model.Table, from, to string) {
+	for _, c := range t.Constraints {
+		if c.ConstraintName == from {
+			c.ConstraintName = to
+		}
+	}
+	for _, idx := range t.Indexes {
+		if idx.IndexName == from {
+			idx.IndexName = to
+		}
+	}
+}
+
+// placeColumn moves column of t to the position given by the mysql FIRST or
+// AFTER clause, if any.
+func placeColumn(c *cursor, t *model.Table, column *model.Column) error {
+	var after string
+	switch {
+	case c.accept("FIRST"):
+	case c.accept("AFTER"):
+		name, err := c.ident()
+		if err != nil {
+			return err
+		}
+		after = name
+	default:
+		return nil
+	}
+
+	var columns []*model.Column
+	position := 0
+	for _, other := range t.Columns {
+		if other == column {
+			continue
+		}
+		columns = append(columns, other)
+		if other.ColumnName == after {
+			position = len(columns)
+		}
+	}
+	t.Columns = append(columns[:position], append([]*model.Column{column}, columns[position:]...)...)
+	return nil
+}
+
+// replaceColumn replaces column name of t keeping its position.
+func (p *Parser) replaceColumn(t *model.Table, name string, column *model.Column) {
+	for i, c := range t.Columns {
+		if c.ColumnName == name {
+			t.Columns[i] = column
+			p.renameColumn(t, name, column.ColumnName)
+			return
+		}
+	}
+}
+
+// renameColumn renames the column of t and its references.
+func (p *Parser) renameColumn(t *model.Table, from, to string) {
+	rename := func(columns []string) {
+		for i, c := range columns {
+			if c == from {
+				columns[i] = to
+			}
+		}
+	}
+	if column := t.Column(from); column != nil {
+		column.ColumnName = to
+	}
+	for _, c := range t.Constraints {
+		rename(c.Columns)
+	}
+	for _, idx := range t.Indexes {
+		for _, column := range idx.Columns {
+			if column.ColumnName == from {
+				column.ColumnName = to
+			}
+		}
+	}
+	for _, tables := range p.tables {
+		for _, other := range tables {
+			for _, fk := range other.ForeignKeys() {
+				if fk.ReferencedTableSchema == t.TableSchema && fk.ReferencedTableName == t.TableName {
+					rename(fk.ReferencedColumns)
+				}
+			}
+		}
+	}
+}
+
+// dropColumn drops the column of t with the constraints and indexes using it.
+func (p *Parser) dropColumn(t *model.Table, name string) {
+	var columns []*model.Column
+	for _, c := range t.Columns {
+		if c.ColumnName != name {
+			columns = append(columns, c)
+		}
+	}
+	t.Columns = columns
+
+	for _, c := range append([]*model.Constraint(nil), t.Constraints...) {
+		for _, column := range c.Columns {
+			if column == name {
+				p.dropConstraint(t, c.ConstraintName)
+			}
+		}
+	}
+	for _, idx := range append([]*model.Index(nil), t.Indexes...) {
+		for _, column := range idx.Columns {
+			if column.ColumnName == name {
+				p.dropConstraint(t, idx.IndexName)
+			}
+		}
+	}
+}
+
+// uniqueName returns base, or base with the first suffix making it unused
+// by the constraints and indexes of t.
+func uniqueName(t *model.Table, base string, suffix func(n int) string) string {
+	name := base
+	for n := 2; t.Constraint(name) != nil || indexNamed(t, name) != nil; n++ {
+		name = base + suffix(n)
+	}
+	return name
+}
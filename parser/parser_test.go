@@ -0,0 +1,178 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+func parse(t *testing.T, dialect, schema, src string) map[string][]*model.Table {
+	p, err := NewParser(dialect, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Parse(src); err != nil {
+		t.Fatal(err)
+	}
+	return p.Tables()
+}
+
+func TestParseMySQL(t *testing.T) {
+	tables := parse(t, "mysql", "shop", "/*!40101 SET NAMES utf8 */;\n"+
+		"CREATE TABLE IF NOT EXISTS `teams` (\n"+
+		"  id int(11) unsigned NOT NULL AUTO_INCREMENT, -- id\n"+
+		"  name varchar(32) NOT NULL DEFAULT 'it''s' COMMENT 'team name',\n"+
+		"  PRIMARY KEY (id)\n"+
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='teams';\n"+`
+CREATE TABLE users (
+  id bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  team_id int(11) unsigned,
+  email varchar(64) NOT NULL,
+  created_at datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+  UNIQUE KEY uk_email (email(10) DESC),
+  FOREIGN KEY (team_id) REFERENCES teams (id) ON DELETE CASCADE,
+  CHECK (id > 0)
+);
+ALTER TABLE users ADD COLUMN nick varchar(10) AFTER email, ADD INDEX idx_nick (nick) INVISIBLE;
+ALTER TABLE users CHANGE nick nickname varchar(20) NULL;
+CREATE INDEX idx_created ON users (created_at);
+`)
+
+	shop := tables["shop"]
+	if len(tables) != 1 || len(shop) != 2 {
+		t.Fatalf("unexpected tables %+v", tables)
+	}
+	teams, users := shop[0], shop[1]
+	if teams.TableComment != "teams" || teams.PrimaryKey() == nil || teams.PrimaryKey().ConstraintName != "PRIMARY" {
+		t.Errorf("unexpected table %+v", teams)
+	}
+	expected := &model.Column{TableSchema: "shop", TableName: "teams", ColumnName: "name", OrdinalPosition: 2,
+		ColumnDefault: "it's", IsNullable: "NO", DataType: "varchar", ColumnType: "varchar(32)",
+		ColumnComment: "team name"}
+	if c := teams.Column("name"); !reflect.DeepEqual(c, expected) {
+		t.Errorf("unexpected column %+v", c)
+	}
+	if c := teams.Column("id"); c.ColumnType != "int(11) unsigned" || c.Extra != "auto_increment" || c.ColumnKey != "PRI" {
+		t.Errorf("unexpected column %+v", c)
+	}
+
+	var columns []string
+	for _, c := range users.Columns {
+		columns = append(columns, c.ColumnName)
+	}
+	if !reflect.DeepEqual(columns, []string{"id", "team_id", "email", "nickname", "created_at"}) {
+		t.Errorf("unexpected columns %q", columns)
+	}
+	if c := users.Column("created_at"); c.ColumnDefault != "CURRENT_TIMESTAMP(3)" ||
+		c.Extra != "DEFAULT_GENERATED on update CURRENT_TIMESTAMP(3)" {
+		t.Errorf("unexpected column %+v", c)
+	}
+
+	fk := users.Constraint("users_ibfk_1")
+	if fk == nil || fk.ReferencedTableSchema != "shop" || fk.ReferencedTableName != "teams" ||
+		!reflect.DeepEqual(fk.ReferencedColumns, []string{"id"}) || fk.DeleteRule != "CASCADE" || fk.UpdateRule != "NO ACTION" {
+		t.Errorf("unexpected foreign key %+v", fk)
+	}
+
+	var indexes []string
+	for _, idx := range users.Indexes {
+		indexes = append(indexes, idx.IndexName)
+	}
+	if !reflect.DeepEqual(indexes, []string{"PRIMARY", "uk_email", "idx_nick", "idx_created", "users_ibfk_1"}) {
+		t.Errorf("unexpected indexes %q", indexes)
+	}
+	if idx := users.Indexes[1]; !idx.Unique || idx.Columns[0].SubPart != 10 || idx.Columns[0].Order != "DESC" {
+		t.Errorf("unexpected index %+v", idx)
+	}
+	if idx := users.Indexes[2]; idx.Visible || idx.Columns[0].ColumnName != "nickname" {
+		t.Errorf("unexpected index %+v", idx)
+	}
+}
+
+func TestParsePGSQL(t *testing.T) {
+	tables := parse(t, "pgsql", "public", `
+CREATE TABLE Teams (
+  id serial PRIMARY KEY,
+  name character varying(32) NOT NULL,
+  tags text[]
+);
+COMMENT ON TABLE teams IS 'teams';
+COMMENT ON COLUMN public.teams.name IS 'team name';
+CREATE FUNCTION touch() RETURNS trigger AS $$ BEGIN DROP TABLE teams; END $$ LANGUAGE plpgsql;
+CREATE TABLE "Users" (
+  id bigint GENERATED BY DEFAULT AS IDENTITY,
+  team_id integer REFERENCES teams ON DELETE SET NULL,
+  email text NOT NULL UNIQUE,
+  created_at timestamp(3) with time zone DEFAULT now(),
+  CONSTRAINT users_pk PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX ON "Users" USING btree (lower(email)) WHERE created_at IS NOT NULL;
+CREATE INDEX idx_tags ON teams USING gin (tags);
+ALTER TABLE "Users" ALTER COLUMN email TYPE varchar(64), ADD COLUMN note text;
+ALTER TABLE "Users" RENAME COLUMN note TO notes;
+`)
+
+	public := tables["public"]
+	if len(public) != 2 {
+		t.Fatalf("unexpected tables %+v", tables)
+	}
+	teams, users := public[0], public[1]
+	if teams.TableName != "teams" || teams.TableComment != "teams" || teams.Column("name").ColumnComment != "team name" {
+		t.Errorf("unexpected table %+v", teams)
+	}
+	expected := &model.Column{TableSchema: "public", TableName: "teams", ColumnName: "id", OrdinalPosition: 1,
+		ColumnDefault: "nextval('teams_id_seq'::regclass)", IsNullable: "N", DataType: "int4",
+		ColumnType: "int4", ColumnKey: "PRI KEY"}
+	if c := teams.Column("id"); !reflect.DeepEqual(c, expected) {
+		t.Errorf("unexpected column %+v", c)
+	}
+	if c := teams.Column("tags"); c.ColumnType != "_text" || !c.Nullable() {
+		t.Errorf("unexpected column %+v", c)
+	}
+	if idx := teams.Indexes[1]; idx.IndexName != "idx_tags" || idx.IndexType != "GIN" {
+		t.Errorf("unexpected index %+v", idx)
+	}
+
+	if users.TableName != "Users" || users.Column("notes") == nil {
+		t.Errorf("unexpected table %+v", users)
+	}
+	if c := users.Column("created_at"); c.ColumnType != "timestamptz(3)" || c.ColumnDefault != "now()" {
+		t.Errorf("unexpected column %+v", c)
+	}
+	if c := users.Column("email"); c.ColumnType != "varchar(64)" || c.ColumnKey != "UNIQUE KEY" {
+		t.Errorf("unexpected column %+v", c)
+	}
+	var constraints []string
+	for _, c := range users.Constraints {
+		constraints = append(constraints, c.ConstraintName)
+	}
+	if !reflect.DeepEqual(constraints, []string{"Users_team_id_fkey", "Users_email_key", "users_pk"}) {
+		t.Errorf("unexpected constraints %q", constraints)
+	}
+	if fk := users.Constraints[0]; !reflect.DeepEqual(fk.ReferencedColumns, []string{"id"}) || fk.DeleteRule != "SET NULL" {
+		t.Errorf("unexpected foreign key %+v", fk)
+	}
+	idx := users.Indexes[2]
+	if idx.IndexName != "Users_expr_idx" || !idx.Unique || idx.Columns[0].Expression != "lower(email)" ||
+		idx.Predicate != "created_at IS NOT NULL" {
+		t.Errorf("unexpected index %+v", idx)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	p, err := NewParser("pgsql", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Parse("CREATE TABLE t (\n  id int,\n  FOREIGN KEY (id) REFERENCES (id)\n);"); err == nil ||
+		err.Error() != `line 3: expected identifier, got "("` {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := p.Parse("ALTER TABLE missing ADD COLUMN id int;"); err == nil {
+		t.Error("expected unknown table")
+	}
+	if _, err := NewParser("sqlite", ""); err == nil {
+		t.Error("expected unsupported dialect")
+	}
+}
@@ -0,0 +1,143 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+type pgsqlDialect struct {
+}
+
+func (pgsqlDialect) fold(name string) string {
+	return strings.ToLower(name)
+}
+
+func (pgsqlDialect) quotedIdentifier(ch byte) bool {
+	return ch == '"'
+}
+
+func (pgsqlDialect) hashComment() bool {
+	return false
+}
+
+func (pgsqlDialect) backslashEscape() bool {
+	return false
+}
+
+func (pgsqlDialect) dollarQuote() bool {
+	return true
+}
+
+// pgsqlTypes maps type names to pg_type.typname
+var pgsqlTypes = map[string]string{
+	"INT":                         "int4",
+	"INTEGER":                     "int4",
+	"SMALLINT":                    "int2",
+	"BIGINT":                      "int8",
+	"SERIAL":                      "int4",
+	"SERIAL4":                     "int4",
+	"SMALLSERIAL":                 "int2",
+	"SERIAL2":                     "int2",
+	"BIGSERIAL":                   "int8",
+	"SERIAL8":                     "int8",
+	"BOOLEAN":                     "bool",
+	"CHARACTER VARYING":           "varchar",
+	"CHARACTER":                   "bpchar",
+	"CHAR":                        "bpchar",
+	"DOUBLE PRECISION":            "float8",
+	"FLOAT":                       "float8",
+	"REAL":                        "float4",
+	"DECIMAL":                     "numeric",
+	"TIMESTAMP WITHOUT TIME ZONE": "timestamp",
+	"TIMESTAMP WITH TIME ZONE":    "timestamptz",
+	"TIME WITHOUT TIME ZONE":      "time",
+	"TIME WITH TIME ZONE":         "timetz",
+}
+
+// columnType returns the typname and the type with its modifier, as the
+// pgsql introspector reports them.
+func (pgsqlDialect) columnType(words []string, args string, array bool) (string, string, bool) {
+	name := strings.Join(words, " ")
+	typname := strings.ToLower(name)
+	if alias, ok := pgsqlTypes[name]; ok {
+		typname = alias
+	}
+	serial := strings.Contains(name, "SERIAL")
+	if array {
+		typname = "_" + typname
+	}
+
+	columnType := typname
+	if args != "" {
+		columnType += "(" + args + ")"
+	}
+	return typname, columnType, serial
+}
+
+func (pgsqlDialect) defaultValue(c *cursor, tokens []token) (string, bool, bool) {
+	if len(tokens) == 0 || len(tokens) == 1 && tokens[0].kind == tokWord && strings.EqualFold(tokens[0].text, "NULL") {
+		return "", true, false
+	}
+	return c.text(tokens), false, false
+}
+
+func (pgsqlDialect) nullable(nullable bool) string {
+	if nullable {
+		return "Y"
+	}
+	return "N"
+}
+
+// constraintName names constraints <table>_<columns>_<pkey|key|fkey>.
+func (pgsqlDialect) constraintName(t *model.Table, c *model.Constraint) string {
+	if c.ConstraintName != "" {
+		return c.ConstraintName
+	}
+	switch c.ConstraintType {
+	case model.PrimaryKey:
+		return t.TableName + "_pkey"
+	case model.ForeignKey:
+		return pgsqlName(t, c.Columns, "fkey")
+	}
+	return pgsqlName(t, c.Columns, "key")
+}
+
+// indexName names indexes <table>_<columns>_idx.
+func (pgsqlDialect) indexName(t *model.Table, idx *model.Index) string {
+	var columns []string
+	for _, column := range idx.Columns {
+		if column.ColumnName != "" {
+			columns = append(columns, column.ColumnName)
+		} else {
+			columns = append(columns, "expr")
+		}
+	}
+	return pgsqlName(t, columns, "idx")
+}
+
+func pgsqlName(t *model.Table, columns []string, suffix string) string {
+	base := t.TableName + "_" + strings.Join(columns, "_") + "_" + suffix
+	return uniqueName(t, base, func(n int) string { return fmt.Sprint(n - 1) })
+}
+
+// finish sets the column keys as the pgsql introspector does, by the first
+// column of the constraints.
+func (pgsqlDialect) finish(t *model.Table) {
+	keys := make(map[string]string)
+	for _, key := range []struct{ constraintType, key string }{
+		{model.ForeignKey, "FOREIGN KEY"},
+		{model.Unique, "UNIQUE KEY"},
+		{model.PrimaryKey, "PRI KEY"},
+	} {
+		for _, c := range t.Constraints {
+			if c.ConstraintType == key.constraintType && len(c.Columns) > 0 {
+				keys[c.Columns[0]] = key.key
+			}
+		}
+	}
+	for _, c := range t.Columns {
+		c.ColumnKey = keys[c.ColumnName]
+	}
+}
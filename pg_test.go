@@ -12,14 +12,20 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"os"
 	"strings"
 	"testing"
 
+	"github.com/Nutao/dbdump/model"
 	_ "github.com/lib/pq"
 )
 
 func TestQueryPG(t *testing.T) {
-	connStr := "user=postgres dbname=postgres password=123456 host=xx.xx.xx.xxc port=5432 sslmode=disable"
+	// e.g. "user=postgres dbname=postgres password=123456 host=127.0.0.1 port=5432 sslmode=disable"
+	connStr := os.Getenv("DBDUMP_TEST_PGSQL")
+	if connStr == "" {
+		t.Skip("DBDUMP_TEST_PGSQL not set")
+	}
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
 		log.Fatal(err)
@@ -35,9 +41,9 @@ func TestQueryPG(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	result := make(map[string][]*Table)
+	result := make(map[string][]*model.Table)
 	for rows.Next() {
-		t := &Table{}
+		t := &model.Table{}
 		if err := rows.Scan(&t.TableSchema, &t.TableName, &t.TableType, &t.TableComment); err != nil {
 			log.Fatal(err)
 		}
@@ -47,10 +53,3 @@ func TestQueryPG(t *testing.T) {
 
 	t.Log(result)
 }
-
-func ValuesInCondition(values []interface{}) (string, []interface{}) {
-	if len(values) == 0 {
-		return "0", values
-	}
-	return fmt.Sprintf("(?%s)", strings.Repeat(",?", len(values)-1)), values
-}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// schemaFilter selects the schemas to dump by the --schemas patterns. The
+// patterns are shell globs, e.g. sales_*, and the ones prefixed with ! exclude
+// schemas. Without include patterns all the non-system schemas are selected.
+type schemaFilter struct {
+	include []string
+	exclude []string
+}
+
+func newSchemaFilter(patterns []string) (*schemaFilter, error) {
+	f := &schemaFilter{}
+	for _, pattern := range patterns {
+		exclude := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return nil, fmt.Errorf("invalid schema pattern %q", pattern)
+		}
+		if exclude {
+			f.exclude = append(f.exclude, pattern)
+		} else {
+			f.include = append(f.include, pattern)
+		}
+	}
+	return f, nil
+}
+
+// Match reports whether the schema is selected. System schemas are selected
+// only by the patterns naming system schemas, e.g. pg_catalog, but not by *.
+func (f *schemaFilter) Match(schema string) bool {
+	for _, pattern := range f.exclude {
+		if ok, _ := path.Match(pattern, schema); ok {
+			return false
+		}
+	}
+	system := pgsqlSystemSchema(schema)
+	if len(f.include) == 0 {
+		return !system
+	}
+	for _, pattern := range f.include {
+		if ok, _ := path.Match(pattern, schema); ok && (!system || pgsqlSystemSchema(pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+// pgsqlSystemSchema reports whether the schema is created by PostgreSQL,
+// names beginning with pg_ are reserved for them.
+func pgsqlSystemSchema(schema string) bool {
+	return schema == "information_schema" || strings.HasPrefix(schema, "pg_")
+}
@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestSchemaFilter(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		schema   string
+		want     bool
+	}{
+		{nil, "public", true},
+		{nil, "sales", true},
+		{nil, "pg_catalog", false},
+		{nil, "pg_toast", false},
+		{nil, "information_schema", false},
+		{[]string{"sales_*"}, "sales_eu", true},
+		{[]string{"sales_*"}, "public", false},
+		{[]string{"sales_*", "!sales_tmp"}, "sales_tmp", false},
+		{[]string{"!audit"}, "audit", false},
+		{[]string{"!audit"}, "public", true},
+		{[]string{"!audit"}, "pg_catalog", false},
+		{[]string{"*"}, "pg_catalog", false},
+		{[]string{"*", "pg_catalog"}, "pg_catalog", true},
+	}
+	for _, tt := range tests {
+		f, err := newSchemaFilter(tt.patterns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := f.Match(tt.schema); got != tt.want {
+			t.Errorf("schemaFilter(%q).Match(%q) = %v, want %v", tt.patterns, tt.schema, got, tt.want)
+		}
+	}
+
+	for _, pattern := range []string{"[", "!"} {
+		if _, err := newSchemaFilter([]string{pattern}); err == nil {
+			t.Errorf("newSchemaFilter(%q) accepted an invalid pattern", pattern)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/Nutao/dbdump/model"
+)
+
+// loadSnapshot loads tables from a file written by the json formatter, only
+// the given tables are kept if any.
+func loadSnapshot(file string, tables []string) (map[string][]*model.Table, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot failed, %w", err)
+	}
+
+	result := make(map[string][]*model.Table)
+	if err := json.Unmarshal(data, &result); err != nil {
+		// or a table per line written with ndjson=true
+		var lineErr error
+		if result, lineErr = loadTableLines(data); lineErr != nil {
+			return nil, fmt.Errorf("parse snapshot %v failed, %w", file, err)
+		}
+	}
+	return filterTables(result, tables), nil
+}
+
+// loadTableLines loads the tables of a snapshot written as ndjson, keyed by
+// their schema.
+func loadTableLines(data []byte) (map[string][]*model.Table, error) {
+	result := make(map[string][]*model.Table)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for dec.More() {
+		t := new(model.Table)
+		if err := dec.Decode(t); err != nil {
+			return nil, err
+		}
+		result[t.TableSchema] = append(result[t.TableSchema], t)
+	}
+	return result, nil
+}
+
+// filterTables keeps the given tables, all tables are kept if none is given.
+func filterTables(allTables map[string][]*model.Table, tables []string) map[string][]*model.Table {
+	if len(tables) == 0 {
+		return allTables
+	}
+	keep := make(map[string]bool)
+	for _, t := range tables {
+		keep[t] = true
+	}
+
+	result := make(map[string][]*model.Table)
+	for schema, tablesInDB := range allTables {
+		for _, t := range tablesInDB {
+			if keep[t.TableName] {
+				result[schema] = append(result[schema], t)
+			}
+		}
+	}
+	return result
+}
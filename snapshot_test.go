@@ -0,0 +1,121 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Nutao/dbdump/formatter"
+	"github.com/Nutao/dbdump/model"
+)
+
+func TestLoadSnapshot(t *testing.T) {
+	tables := map[string][]*model.Table{
+		"shop": {
+			{TableSchema: "shop", TableName: "users", Columns: []*model.Column{
+				{TableSchema: "shop", TableName: "users", ColumnName: "id", ColumnType: "int(11)", IsNullable: "NO"},
+			}, Indexes: []*model.Index{
+				{IndexName: "PRIMARY", Primary: true, Columns: []*model.IndexColumn{{ColumnName: "id", Order: "ASC"}}},
+			}},
+			{TableSchema: "shop", TableName: "orders"},
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "dbdump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, config := range []string{"", "indent=2", "ndjson=true"} {
+		f, err := formatter.NewFormatter("json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Initialize([]byte(config)); err != nil {
+			t.Fatal(err)
+		}
+		data, err := f.Format(tables)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		file := filepath.Join(dir, "snapshot.json")
+		if err := ioutil.WriteFile(file, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		loaded, err := loadSnapshot(file, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(loaded, tables) {
+			t.Errorf("unexpected snapshot %+v of %q", loaded, config)
+		}
+
+		loaded, err = loadSnapshot(file, []string{"orders"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(loaded["shop"]) != 1 || loaded["shop"][0].TableName != "orders" {
+			t.Errorf("unexpected filtered snapshot %+v of %q", loaded, config)
+		}
+	}
+}
+
+// schemaIntrospector returns the schema it holds instead of querying a database.
+type schemaIntrospector struct {
+	constraints map[string]map[string]map[string]*model.Constraint
+}
+
+func (schemaIntrospector) Open(*config) error { return nil }
+func (schemaIntrospector) Close() error       { return nil }
+
+func (schemaIntrospector) LoadTables() (map[string][]*model.Table, error) {
+	return map[string][]*model.Table{"shop": {{TableSchema: "shop", TableName: "orders"}}}, nil
+}
+
+func (schemaIntrospector) LoadColumns(map[string][]*model.Table) (map[string]map[string][]*model.Column, error) {
+	return nil, nil
+}
+
+func (i schemaIntrospector) LoadConstraints() (map[string]map[string]map[string]*model.Constraint, error) {
+	return i.constraints, nil
+}
+
+func (schemaIntrospector) LoadIndexes() (map[string]map[string][]*model.Index, error) {
+	return nil, nil
+}
+
+// TestLoadSchemaConstraintOrder checks that the constraints keyed by name are
+// sorted, so that snapshots of the same schema are identical.
+func TestLoadSchemaConstraintOrder(t *testing.T) {
+	constraints := map[string]*model.Constraint{}
+	for _, c := range []*model.Constraint{
+		{ConstraintName: "uk_no", ConstraintType: model.Unique},
+		{ConstraintName: "fk_user", ConstraintType: model.ForeignKey},
+		{ConstraintName: "PRIMARY", ConstraintType: model.PrimaryKey},
+		{ConstraintName: "fk_address", ConstraintType: model.ForeignKey},
+	} {
+		constraints[c.ConstraintName] = c
+	}
+	in := schemaIntrospector{constraints: map[string]map[string]map[string]*model.Constraint{
+		"shop": {"orders": constraints},
+	}}
+	expected := []string{"fk_address", "fk_user", "PRIMARY", "uk_no"}
+	for i := 0; i < 10; i++ {
+		tables, err := loadSchema(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, c := range tables["shop"][0].Constraints {
+			names = append(names, c.ConstraintName)
+		}
+		if !reflect.DeepEqual(names, expected) {
+			t.Fatalf("unexpected constraints %v", names)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// mysqlSockets are the default socket files of MySQL and MariaDB packages
+var mysqlSockets = []string{
+	"/var/run/mysqld/mysqld.sock",
+	"/run/mysqld/mysqld.sock",
+	"/var/lib/mysql/mysql.sock",
+	"/tmp/mysql.sock",
+	"/var/mysql/mysql.sock",
+}
+
+// pgsqlSocketDirs are the default directories of the PostgreSQL sockets,
+// the socket file is named .s.PGSQL.<port>
+var pgsqlSocketDirs = []string{
+	"/var/run/postgresql",
+	"/run/postgresql",
+	"/tmp",
+	"/private/tmp",
+}
+
+// pgsqlSocketPrefix is the name of the PostgreSQL socket file without the port
+const pgsqlSocketPrefix = ".s.PGSQL."
+
+// resolveSocket replaces the socket auto with the first existing default
+// socket of the db type.
+func resolveSocket(cfg *config) error {
+	if cfg.Socket != "auto" {
+		return nil
+	}
+	var candidates []string
+	switch cfg.DBType {
+	case "mysql":
+		candidates = mysqlSockets
+	case "pgsql":
+		for _, dir := range pgsqlSocketDirs {
+			candidates = append(candidates, filepath.Join(dir, pgsqlSocketPrefix+strconv.FormatUint(uint64(cfg.Port), 10)))
+		}
+	default:
+		return fmt.Errorf("%v database does not use sockets", cfg.DBType)
+	}
+	for _, file := range candidates {
+		if info, err := os.Stat(file); err == nil && info.Mode()&os.ModeSocket != 0 {
+			cfg.Socket = file
+			return nil
+		}
+	}
+	return fmt.Errorf("no %v socket found in %v", cfg.DBType, strings.Join(candidates, ", "))
+}
+
+// pgsqlSocket returns the directory and the port of the socket, which pq
+// takes as the host and port parameters. The socket is a socket file or the
+// directory of it.
+func pgsqlSocket(socket string, port uint) (string, uint) {
+	name := filepath.Base(socket)
+	if strings.HasPrefix(name, pgsqlSocketPrefix) {
+		if n, err := strconv.ParseUint(strings.TrimPrefix(name, pgsqlSocketPrefix), 10, 32); err == nil {
+			return filepath.Dir(socket), uint(n)
+		}
+	}
+	return socket, port
+}
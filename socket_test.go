@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPgsqlSocket(t *testing.T) {
+	tests := []struct {
+		socket   string
+		wantDir  string
+		wantPort uint
+	}{
+		{"/var/run/postgresql", "/var/run/postgresql", 5432},
+		{"/tmp/.s.PGSQL.5433", "/tmp", 5433},
+		{"/tmp/.s.PGSQL.x", "/tmp/.s.PGSQL.x", 5432},
+	}
+	for _, tt := range tests {
+		dir, port := pgsqlSocket(tt.socket, 5432)
+		if dir != tt.wantDir || port != tt.wantPort {
+			t.Errorf("pgsqlSocket(%q) = %q, %v, want %q, %v", tt.socket, dir, port, tt.wantDir, tt.wantPort)
+		}
+	}
+}
+
+func TestResolveSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dbdump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, ".s.PGSQL.5433")
+	l, err := net.Listen("unix", file)
+	if err != nil {
+		t.Skip("unix sockets are not supported:", err)
+	}
+	defer l.Close()
+
+	saved := pgsqlSocketDirs
+	defer func() { pgsqlSocketDirs = saved }()
+	pgsqlSocketDirs = []string{filepath.Join(dir, "missing"), dir}
+
+	cfg := &config{DBType: "pgsql", Port: 5433, Socket: "auto"}
+	if err := resolveSocket(cfg); err != nil || cfg.Socket != file {
+		t.Errorf("resolveSocket() = %q, %v, want %q", cfg.Socket, err, file)
+	}
+	cfg = &config{DBType: "pgsql", Port: 5432, Socket: "auto"}
+	if err := resolveSocket(cfg); err == nil {
+		t.Errorf("resolveSocket() found %q for another port", cfg.Socket)
+	}
+}
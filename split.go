@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/Nutao/dbdump/formatter"
+	"github.com/Nutao/dbdump/model"
+)
+
+// splitName is the data of the output path pattern, TableName is empty if the
+// output is split by schema. The names are safe to be used as path elements.
+type splitName struct {
+	TableSchema string
+	TableName   string
+}
+
+// splitPart is the output of a table or schema.
+type splitPart struct {
+	name    splitName
+	schema  string
+	table   string // empty if split by schema
+	comment string
+	path    string
+	tables  map[string][]*model.Table
+}
+
+// splitOutput runs the formatter per table or per schema and writes each output
+// to the path of the --output pattern, e.g. docs/{{.TableSchema}}/{{.TableName}}.md
+func splitOutput(allTables map[string][]*model.Table) error {
+	if gConfig.Output == "" {
+		return fmt.Errorf("the output path pattern is required to split the output by %v", gConfig.Split)
+	}
+	pattern, err := template.New("output").Funcs(formatter.TemplateFuncs()).Parse(gConfig.Output)
+	if err != nil {
+		return fmt.Errorf("parse output path pattern failed, %w", err)
+	}
+
+	// all tables are loaded for a focused diagram, each part is the diagram
+	// around the tables of --tables in it
+	selected := allTables
+	focus, focused := gConfig.Formatter.(formatter.FocusFormatter)
+	if focused = focused && focus.Focus(gConfig.Tables); focused {
+		selected = filterTables(allTables, gConfig.Tables)
+	}
+
+	schemas := make([]string, 0, len(selected))
+	for schema := range selected {
+		schemas = append(schemas, schema)
+	}
+	sort.Strings(schemas)
+	var parts []*splitPart
+	for _, schema := range schemas {
+		if gConfig.Split == "schema" {
+			parts = append(parts, &splitPart{
+				name:   splitName{TableSchema: pathElement(schema)},
+				schema: schema,
+				tables: map[string][]*model.Table{schema: selected[schema]},
+			})
+			continue
+		}
+		for _, t := range selected[schema] {
+			parts = append(parts, &splitPart{
+				name:    splitName{TableSchema: pathElement(schema), TableName: pathElement(t.TableName)},
+				schema:  schema,
+				table:   t.TableName,
+				comment: t.TableComment,
+				tables:  map[string][]*model.Table{schema: {t}},
+			})
+		}
+	}
+
+	written := make(map[string]*splitPart)
+	for _, part := range parts {
+		buffer := &bytes.Buffer{}
+		if err := pattern.Execute(buffer, part.name); err != nil {
+			return fmt.Errorf("execute output path pattern failed, %w", err)
+		}
+		part.path = filepath.Clean(buffer.String())
+		if other, ok := written[part.path]; ok {
+			return fmt.Errorf("%v and %v are both written to %v, the output path pattern should use the %v name",
+				other, part, part.path, gConfig.Split)
+		}
+		written[part.path] = part
+
+		if f, ok := gConfig.Formatter.(formatter.FileFormatter); ok {
+			files, err := f.FormatFiles(part.tables)
+			if err != nil {
+				return fmt.Errorf("formate output of %v failed, %w", part, err)
+			}
+			if files != nil {
+				return fmt.Errorf("%v format writes a set of files, the output can not be split by %v",
+					gConfig.FormatType, gConfig.Split)
+			}
+		}
+		input := part.tables
+		if focused {
+			var names []string
+			for _, tables := range part.tables {
+				for _, t := range tables {
+					names = append(names, t.TableName)
+				}
+			}
+			focus.Focus(names)
+			input = allTables
+		}
+		data, err := gConfig.Formatter.Format(input)
+		if err != nil {
+			return fmt.Errorf("formate output of %v failed, %w", part, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(part.path), 0755); err != nil {
+			return fmt.Errorf("create output directory failed, %w", err)
+		}
+		if err := ioutil.WriteFile(part.path, data, 0644); err != nil {
+			return fmt.Errorf("write to output file failed, %w", err)
+		}
+	}
+
+	if gConfig.Index != "" {
+		return writeIndex(gConfig.Index, parts)
+	}
+	return nil
+}
+
+// String returns the qualified name of the table or the schema.
+func (p *splitPart) String() string {
+	if p.table == "" {
+		return p.schema
+	}
+	return p.schema + "." + p.table
+}
+
+// pathElement replaces the characters which can not be used in a file name.
+func pathElement(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == ".." {
+		return strings.Repeat("_", len(name)+1)
+	}
+	return name
+}
+
+// writeIndex writes the index of the outputs linking to them by paths relative
+// to the index: a list of links for .md and .html files, otherwise a path per line.
+func writeIndex(file string, parts []*splitPart) error {
+	dir := filepath.Dir(file)
+	links := make([]string, len(parts))
+	for i, part := range parts {
+		rel, err := filepath.Rel(dir, part.path)
+		if err != nil {
+			return fmt.Errorf("write index failed, %w", err)
+		}
+		links[i] = filepath.ToSlash(rel)
+	}
+
+	var b strings.Builder
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".md", ".markdown":
+		schema := ""
+		for i, part := range parts {
+			if part.table == "" {
+				fmt.Fprintf(&b, "- [%v](%v)\n", part.schema, markdownURL(links[i]))
+				continue
+			}
+			if part.schema != schema {
+				if schema != "" {
+					b.WriteString("\n")
+				}
+				schema = part.schema
+				fmt.Fprintf(&b, "## %v\n\n", schema)
+			}
+			fmt.Fprintf(&b, "- [%v](%v)", part.table, markdownURL(links[i]))
+			if part.comment != "" {
+				b.WriteString(" " + strings.Join(strings.Fields(part.comment), " "))
+			}
+			b.WriteString("\n")
+		}
+	case ".html", ".htm":
+		b.WriteString("<!DOCTYPE html>\n<html>\n<head><meta charset=\"utf-8\"><title>Index</title></head>\n<body>\n<ul>\n")
+		for i, part := range parts {
+			fmt.Fprintf(&b, "<li><a href=\"%v\">%v</a>", html.EscapeString(links[i]), html.EscapeString(part.String()))
+			if part.comment != "" {
+				b.WriteString(" " + html.EscapeString(part.comment))
+			}
+			b.WriteString("</li>\n")
+		}
+		b.WriteString("</ul>\n</body>\n</html>\n")
+	default:
+		for _, link := range links {
+			b.WriteString(link + "\n")
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create index directory failed, %w", err)
+	}
+	if err := ioutil.WriteFile(file, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("write index failed, %w", err)
+	}
+	return nil
+}
+
+// markdownURL escapes the characters of the link breaking markdown links.
+func markdownURL(link string) string {
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29").Replace(link)
+}
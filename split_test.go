@@ -0,0 +1,120 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Nutao/dbdump/formatter"
+	"github.com/Nutao/dbdump/model"
+)
+
+func TestSplitOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dbdump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := formatter.NewFormatter("gotext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Initialize([]byte(`{{range $s, $tables := .}}{{range $tables}}{{$s}}.{{.TableName}}{{end}}{{end}}`)); err != nil {
+		t.Fatal(err)
+	}
+	saved := *gConfig
+	defer func() { *gConfig = saved }()
+	gConfig.Formatter = f
+	gConfig.Split = "table"
+	gConfig.Output = filepath.Join(dir, "docs", "{{.TableSchema}}", "{{.TableName | snake}}.md")
+	gConfig.Index = filepath.Join(dir, "docs", "README.md")
+
+	tables := map[string][]*model.Table{
+		"shop": {{TableName: "OrderItems", TableComment: "订单\n明细"}, {TableName: "a/b"}},
+		"crm":  {{TableName: "users"}},
+	}
+	if err := splitOutput(tables); err != nil {
+		t.Fatal(err)
+	}
+	for file, expected := range map[string]string{
+		"docs/shop/order_items.md": "shop.OrderItems",
+		"docs/shop/a_b.md":         "shop.a/b",
+		"docs/crm/users.md":        "crm.users",
+		"docs/README.md":           "## crm\n\n- [users](crm/users.md)\n\n## shop\n\n- [OrderItems](shop/order_items.md) 订单 明细\n- [a/b](shop/a_b.md)\n",
+	} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != expected {
+			t.Errorf("unexpected %v\n%s", file, data)
+		}
+	}
+
+	gConfig.Output = filepath.Join(dir, "{{.TableSchema}}.md")
+	if err := splitOutput(tables); err == nil {
+		t.Error("expected tables written to the same file")
+	}
+}
+
+func TestSplitOutputFormatters(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dbdump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	saved := *gConfig
+	defer func() { *gConfig = saved }()
+	gConfig.Split = "table"
+	gConfig.Output = filepath.Join(dir, "{{.TableName}}.dot")
+	gConfig.Index = ""
+	tables := map[string][]*model.Table{"shop": {
+		{TableName: "users"},
+		{TableName: "orders", Constraints: []*model.Constraint{{ConstraintName: "fk_user",
+			ConstraintType: model.ForeignKey, Columns: []string{"user_id"}, ReferencedTableName: "users"}}},
+		{TableName: "logs"},
+	}}
+
+	// the diagram of each table of --tables shows its neighbours
+	f, err := formatter.NewFormatter("dot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Initialize([]byte("columns=none&hops=1")); err != nil {
+		t.Fatal(err)
+	}
+	gConfig.Formatter, gConfig.FormatType, gConfig.Tables = f, "dot", []string{"users"}
+	if err := splitOutput(tables); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "users.dot"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"orders" -> "users"`) || strings.Contains(string(data), "logs") {
+		t.Errorf("unexpected diagram\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "orders.dot")); !os.IsNotExist(err) {
+		t.Errorf("expected only the tables of --tables written, %v", err)
+	}
+
+	// a site can not be split
+	if gConfig.Formatter, err = formatter.NewFormatter("html"); err != nil {
+		t.Fatal(err)
+	}
+	if err := gConfig.Formatter.Initialize(nil); err != nil {
+		t.Fatal(err)
+	}
+	gConfig.FormatType, gConfig.Tables = "html", nil
+	gConfig.Output = filepath.Join(dir, "html", "{{.TableName}}.html")
+	if err := splitOutput(tables); err == nil || !strings.Contains(err.Error(), "can not be split") {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "html")); !os.IsNotExist(err) {
+		t.Errorf("expected nothing written, %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// sslModes are the supported values of --ssl_mode, named after the sslmode
+// of PostgreSQL:
+//
+//	disable      no TLS
+//	require      TLS without verifying the server certificate
+//	verify-ca    verify the server certificate is signed by the CA
+//	verify-full  verify the CA and that the certificate matches the server name
+var sslModes = []string{"disable", "require", "verify-ca", "verify-full"}
+
+// mysqlTLSConfigs counts the TLS configs registered to the mysql driver
+var mysqlTLSConfigs int
+
+// sslMode returns the ssl mode of cfg, verify-full if a CA is given without a
+// mode, disable otherwise.
+func sslMode(cfg *config) (string, error) {
+	mode := cfg.SSLMode
+	if mode == "" {
+		mode = "disable"
+		if cfg.SSLCA != "" {
+			mode = "verify-full"
+		}
+	}
+	supported := false
+	for _, m := range sslModes {
+		supported = supported || m == mode
+	}
+	if !supported {
+		return "", fmt.Errorf("unsupported ssl mode %q", mode)
+	}
+	if (cfg.SSLCert == "") != (cfg.SSLKey == "") {
+		return "", errors.New("ssl_cert and ssl_key must be given together")
+	}
+	return mode, nil
+}
+
+// mysqlTLS registers the TLS config of cfg to the mysql driver, and returns
+// the value of the tls parameter of the DSN.
+func mysqlTLS(cfg *config) (string, error) {
+	mode, err := sslMode(cfg)
+	if err != nil || mode == "disable" {
+		return "false", err
+	}
+
+	conf := &tls.Config{}
+	if cfg.SSLCA != "" {
+		data, err := ioutil.ReadFile(cfg.SSLCA)
+		if err != nil {
+			return "", fmt.Errorf("read ssl ca failed, %w", err)
+		}
+		conf.RootCAs = x509.NewCertPool()
+		if !conf.RootCAs.AppendCertsFromPEM(data) {
+			return "", fmt.Errorf("no certificate found in %v", cfg.SSLCA)
+		}
+	}
+	if cfg.SSLCert != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.SSLCert, cfg.SSLKey)
+		if err != nil {
+			return "", fmt.Errorf("load ssl client certificate failed, %w", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+
+	switch mode {
+	case "require":
+		conf.InsecureSkipVerify = true
+	case "verify-ca":
+		// the chain is verified by hand, without the host name
+		conf.InsecureSkipVerify = true
+		conf.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyChain(rawCerts, conf.RootCAs)
+		}
+	case "verify-full":
+		conf.ServerName = cfg.Host
+		if cfg.SSLServerName != "" {
+			conf.ServerName = cfg.SSLServerName
+		}
+	}
+
+	mysqlTLSConfigs++
+	name := fmt.Sprintf("dbdump%d", mysqlTLSConfigs)
+	if err := mysql.RegisterTLSConfig(name, conf); err != nil {
+		return "", fmt.Errorf("register tls config failed, %w", err)
+	}
+	return name, nil
+}
+
+// verifyChain verifies the certificates sent by the server are signed by the
+// roots, or by the system roots if roots is nil.
+func verifyChain(rawCerts [][]byte, roots *x509.CertPool) error {
+	if len(rawCerts) == 0 {
+		return errors.New("no server certificate")
+	}
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		certs[i] = cert
+	}
+	opts := x509.VerifyOptions{Roots: roots, Intermediates: x509.NewCertPool()}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(opts)
+	return err
+}
+
+// pgsqlSSLParams returns the ssl parameters of the pq DSN.
+func pgsqlSSLParams(cfg *config) (url.Values, error) {
+	mode, err := sslMode(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// pq verifies the certificate against the host and can not be told otherwise
+	if cfg.SSLServerName != "" && cfg.SSLServerName != cfg.Host {
+		return nil, errors.New("ssl_server_name is not supported by pgsql, connect to the host named in the certificate instead")
+	}
+	params := url.Values{"sslmode": {mode}}
+	if cfg.SSLCA != "" {
+		params.Set("sslrootcert", cfg.SSLCA)
+	}
+	if cfg.SSLCert != "" {
+		params.Set("sslcert", cfg.SSLCert)
+		params.Set("sslkey", cfg.SSLKey)
+	}
+	return params, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestSSLMode(t *testing.T) {
+	tests := []struct {
+		cfg    config
+		want   string
+		hasErr bool
+	}{
+		{cfg: config{}, want: "disable"},
+		{cfg: config{SSLCA: "ca.pem"}, want: "verify-full"},
+		{cfg: config{SSLMode: "verify-ca", SSLCA: "ca.pem"}, want: "verify-ca"},
+		{cfg: config{SSLMode: "require", SSLCert: "client.pem", SSLKey: "client.key"}, want: "require"},
+		{cfg: config{SSLMode: "prefer"}, hasErr: true},
+		{cfg: config{SSLMode: "require", SSLCert: "client.pem"}, hasErr: true},
+	}
+	for _, tt := range tests {
+		got, err := sslMode(&tt.cfg)
+		if (err != nil) != tt.hasErr || got != tt.want {
+			t.Errorf("sslMode(%+v) = %q, %v, want %q", tt.cfg, got, err, tt.want)
+		}
+	}
+}
+
+func TestPgsqlSSLParams(t *testing.T) {
+	cfg := &config{Host: "db.example.com", SSLCA: "ca.pem", SSLCert: "client.pem", SSLKey: "client.key"}
+	got, err := pgsqlSSLParams(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := url.Values{
+		"sslmode":     {"verify-full"},
+		"sslrootcert": {"ca.pem"},
+		"sslcert":     {"client.pem"},
+		"sslkey":      {"client.key"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pgsqlSSLParams() = %v, want %v", got, want)
+	}
+
+	cfg.SSLServerName = "other.example.com"
+	if _, err := pgsqlSSLParams(cfg); err == nil {
+		t.Errorf("pgsqlSSLParams() accepted a server name")
+	}
+}
+
+func TestMysqlTLS(t *testing.T) {
+	if name, err := mysqlTLS(&config{}); err != nil || name != "false" {
+		t.Errorf("mysqlTLS() = %q, %v, want false", name, err)
+	}
+	if name, err := mysqlTLS(&config{SSLMode: "require"}); err != nil || name == "false" {
+		t.Errorf("mysqlTLS(require) = %q, %v, want a registered config", name, err)
+	}
+	if _, err := mysqlTLS(&config{SSLCA: "testdata/missing.pem"}); err == nil {
+		t.Errorf("mysqlTLS() accepted a missing ca file")
+	}
+}